@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/claimcheck"
+	"github.com/ankit-lilly/dtd-go-backend/internal/dlq"
+	"github.com/ankit-lilly/dtd-go-backend/internal/submission"
+)
+
+func (a *app) list(ctx context.Context, f filter) error {
+	matches, err := a.scan(ctx, f)
+	if err != nil {
+		return err
+	}
+	defer a.release(ctx, matches)
+
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "MESSAGE ID\tSTUDY ID\tSTAGE\tERROR CODE\tRECEIVES\tTIMESTAMP\tERROR")
+	for _, m := range matches {
+		envelope, err := m.Envelope()
+		if err != nil || envelope == nil {
+			// Moved by the redrive policy, or by a processor that did
+			// not write envelopes yet.
+			fmt.Fprintf(w, "%s\t%s\t-\t-\t-\t-\t-\n", m.ID, orDash(m.StudyID()))
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", m.ID, orDash(m.StudyID()), envelope.Stage, envelope.Code,
+			envelope.ReceiveCount, envelope.Timestamp.Format("2006-01-02T15:04:05Z"), truncate(envelope.Error, 80))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	log.Printf("%d messages", len(matches))
+	return nil
+}
+
+func (a *app) peek(ctx context.Context, f filter) error {
+	matches, err := a.scan(ctx, f)
+	if err != nil {
+		return err
+	}
+	defer a.release(ctx, matches)
+
+	for _, m := range matches {
+		data, err := describe(m)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(a.out, "%s\n", data)
+	}
+	return nil
+}
+
+// export writes every matching message to dir/<message id>.json. The
+// messages stay on the queue.
+func (a *app) export(ctx context.Context, f filter, dir string) error {
+	matches, err := a.scan(ctx, f)
+	if err != nil {
+		return err
+	}
+	defer a.release(ctx, matches)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	for _, m := range matches {
+		data, err := describe(m)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, m.ID+".json")
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	log.Printf("Exported %d messages to %s", len(matches), dir)
+	return nil
+}
+
+// redrive sends every matching message back to the queue without its
+// error envelope, rewritten by transform if one is given, and then deletes
+// it from the dead letter queue. A message that fails to redrive is left on
+// the dead letter queue and the others are still tried.
+func (a *app) redrive(ctx context.Context, f filter, transform string, dryRun bool) error {
+	matches, err := a.scan(ctx, f)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, m := range matches {
+		out, err := a.prepare(ctx, m.WithoutEnvelope(), transform)
+		if err == nil && !dryRun {
+			var id string
+			if id, err = a.queue.Send(ctx, out); err == nil {
+				log.Printf("Redrove message %s as %s", m.ID, id)
+				err = a.deadLetters.Delete(ctx, m)
+			}
+		}
+		if dryRun && err == nil {
+			fmt.Fprintf(a.out, "would redrive %s: %s\n", m.ID, truncate(out.Body, 200))
+		}
+		if err != nil || dryRun {
+			a.release(ctx, []dlq.Message{m})
+		}
+		if err != nil {
+			log.Printf("Failed to redrive message %s: %v", m.ID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to redrive %d of %d messages", failed, len(matches))
+	}
+	if dryRun {
+		log.Printf("Would redrive %d messages", len(matches))
+		return nil
+	}
+	log.Printf("Redrove %d messages", len(matches))
+	return nil
+}
+
+// prepare applies transform to the payload of m. A payload sent by
+// reference is read first, and is sent inline again if the transformed
+// payload fits in a message.
+func (a *app) prepare(ctx context.Context, m dlq.Message, transform string) (dlq.Message, error) {
+	if transform == "" {
+		return m, nil
+	}
+
+	payload := []byte(m.Body)
+	if location := m.Attributes[claimcheck.MessageAttribute]; location != "" {
+		var err error
+		if payload, err = a.payloads.Get(ctx, location); err != nil {
+			return m, err
+		}
+	}
+
+	fixed, err := runTransform(ctx, transform, payload)
+	if err != nil {
+		return m, err
+	}
+
+	delete(m.Attributes, claimcheck.MessageAttribute)
+	m.Body = string(fixed)
+	if len(fixed) > claimcheck.MaxInlineSize {
+		submissionID := m.Attributes[submission.MessageAttribute]
+		if submissionID == "" {
+			submissionID = m.ID
+		}
+		location, err := a.payloads.Put(ctx, submissionID, fixed)
+		if err != nil {
+			return m, err
+		}
+		if m.Attributes == nil {
+			m.Attributes = map[string]string{}
+		}
+		m.Attributes[claimcheck.MessageAttribute] = location
+		m.Body = location
+	}
+	return m, nil
+}
+
+func runTransform(ctx context.Context, transform string, payload []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", transform)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("transform failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	fixed := bytes.TrimSpace(stdout.Bytes())
+	if !json.Valid(fixed) {
+		return nil, errors.New("transform did not write a JSON payload")
+	}
+	return fixed, nil
+}
+
+// describe renders a message for peek and export: its envelope decoded
+// and its body kept as JSON if it is JSON.
+func describe(m dlq.Message) ([]byte, error) {
+	envelope, err := m.Envelope()
+	if err != nil {
+		return nil, err
+	}
+
+	body := json.RawMessage(m.Body)
+	if !json.Valid(body) {
+		body, _ = json.Marshal(m.Body)
+	}
+
+	return json.MarshalIndent(struct {
+		MessageID  string            `json:"messageId"`
+		Attributes map[string]string `json:"attributes,omitempty"`
+		Envelope   *dlq.Envelope     `json:"envelope,omitempty"`
+		Body       json.RawMessage   `json:"body"`
+	}{m.ID, m.WithoutEnvelope().Attributes, envelope, body}, "", "  ")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// truncate cuts s to at most n bytes, backing off to the start of the rune
+// the cut would split.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
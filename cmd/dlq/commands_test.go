@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/claimcheck"
+	"github.com/ankit-lilly/dtd-go-backend/internal/dlq"
+	"github.com/ankit-lilly/dtd-go-backend/internal/submission"
+)
+
+// memoryPayloads is a payloadStore kept in a map.
+type memoryPayloads map[string][]byte
+
+func (p memoryPayloads) Get(_ context.Context, location string) ([]byte, error) {
+	payload, ok := p[location]
+	if !ok {
+		return nil, claimcheck.ErrNotFound
+	}
+	return payload, nil
+}
+
+func (p memoryPayloads) Put(_ context.Context, submissionID string, payload []byte) (string, error) {
+	location := "s3://payloads/" + submissionID
+	p[location] = payload
+	return location, nil
+}
+
+// deadLettered returns a message of study studyID dead-lettered with code.
+func deadLettered(t *testing.T, studyID string, code dlq.Code) dlq.Message {
+	t.Helper()
+	m := dlq.Message{
+		Body:       fmt.Sprintf(`{"usdmVersion":"2.0.0","study":{"id":%q}}`, studyID),
+		Attributes: map[string]string{submission.MessageAttribute: "sub-" + studyID},
+	}
+	m, err := m.WithEnvelope(dlq.Envelope{StudyID: studyID, Stage: dlq.Parse, Code: code, Error: "bad"})
+	if err != nil {
+		t.Fatalf("WithEnvelope: %v", err)
+	}
+	return m
+}
+
+// newApp returns an app over an in-memory dead letter queue holding
+// messages, and the queue to redrive to.
+func newApp(t *testing.T, messages ...dlq.Message) (*app, *dlq.MemoryQueue, *dlq.MemoryQueue) {
+	t.Helper()
+	deadLetters, queue := dlq.NewMemoryQueue(), dlq.NewMemoryQueue()
+	for _, m := range messages {
+		if _, err := deadLetters.Send(context.Background(), m); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	return &app{
+		deadLetters: deadLetters,
+		queue:       queue,
+		payloads:    memoryPayloads{},
+		visibility:  time.Minute,
+		out:         &bytes.Buffer{},
+	}, deadLetters, queue
+}
+
+// visible returns the study ids of the messages that can be received from
+// q, which is all of them once a command has released what it kept.
+func visible(t *testing.T, q *dlq.MemoryQueue) []string {
+	t.Helper()
+	received, err := q.Receive(context.Background(), dlq.MaxReceive, time.Second)
+	if err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	var ids []string
+	for _, m := range received {
+		ids = append(ids, m.StudyID())
+		q.Release(context.Background(), m)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestFilterMatch(t *testing.T) {
+	m := deadLettered(t, "S1", dlq.MalformedPayload)
+	m.ID = "m-1"
+	noEnvelope := m.WithoutEnvelope()
+
+	tests := []struct {
+		name   string
+		filter filter
+		m      dlq.Message
+		want   bool
+	}{
+		{"everything", filter{}, m, true},
+		{"study", filter{studyID: "S1"}, m, true},
+		{"other study", filter{studyID: "S2"}, m, false},
+		{"code", filter{code: string(dlq.MalformedPayload)}, m, true},
+		{"other code", filter{code: string(dlq.GraphUnavailable)}, m, false},
+		{"code without envelope", filter{code: string(dlq.MalformedPayload)}, noEnvelope, false},
+		{"study from body", filter{studyID: "S1"}, noEnvelope, true},
+		{"message id", filter{messageIDs: map[string]bool{"m-1": true}}, m, true},
+		{"other message id", filter{messageIDs: map[string]bool{"m-2": true}}, m, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.match(tt.m); got != tt.want {
+			t.Errorf("%s: match = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRedrive(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("filtered", func(t *testing.T) {
+		a, deadLetters, queue := newApp(t,
+			deadLettered(t, "S1", dlq.MalformedPayload),
+			deadLettered(t, "S2", dlq.GraphUnavailable),
+			deadLettered(t, "S3", dlq.MalformedPayload),
+		)
+		if err := a.redrive(ctx, filter{code: string(dlq.MalformedPayload)}, "", false); err != nil {
+			t.Fatalf("redrive: %v", err)
+		}
+		if got := visible(t, deadLetters); !slices.Equal(got, []string{"S2"}) {
+			t.Errorf("left on the dead letter queue: %v, want S2", got)
+		}
+		redriven := queue.Messages()
+		if len(redriven) != 2 {
+			t.Fatalf("redrove %d messages, want 2", len(redriven))
+		}
+		for _, m := range redriven {
+			if _, ok := m.Attributes[dlq.EnvelopeAttribute]; ok {
+				t.Errorf("redriven message %s still carries its envelope", m.StudyID())
+			}
+			if m.Attributes[submission.MessageAttribute] == "" {
+				t.Errorf("redriven message %s lost its submission id", m.StudyID())
+			}
+		}
+	})
+
+	t.Run("max", func(t *testing.T) {
+		a, deadLetters, queue := newApp(t, deadLettered(t, "S1", dlq.MalformedPayload), deadLettered(t, "S2", dlq.MalformedPayload))
+		if err := a.redrive(ctx, filter{max: 1}, "", false); err != nil {
+			t.Fatalf("redrive: %v", err)
+		}
+		if len(queue.Messages()) != 1 || len(visible(t, deadLetters)) != 1 {
+			t.Errorf("max 1 redrove %d messages", len(queue.Messages()))
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		a, deadLetters, queue := newApp(t, deadLettered(t, "S1", dlq.MalformedPayload))
+		if err := a.redrive(ctx, filter{}, `sed 's/2.0.0/3.0.0/'`, true); err != nil {
+			t.Fatalf("redrive: %v", err)
+		}
+		if len(queue.Messages()) != 0 {
+			t.Error("a dry run sent messages")
+		}
+		if got := visible(t, deadLetters); !slices.Equal(got, []string{"S1"}) {
+			t.Errorf("left on the dead letter queue: %v, want S1", got)
+		}
+		if out := a.out.(*bytes.Buffer).String(); !strings.Contains(out, `"usdmVersion":"3.0.0"`) {
+			t.Errorf("dry run printed %q, want the transformed payload", out)
+		}
+	})
+
+	t.Run("transform", func(t *testing.T) {
+		a, _, queue := newApp(t, deadLettered(t, "S1", dlq.MalformedPayload))
+		if err := a.redrive(ctx, filter{}, `sed 's/2.0.0/3.0.0/'`, false); err != nil {
+			t.Fatalf("redrive: %v", err)
+		}
+		if got := queue.Messages()[0].Body; got != `{"usdmVersion":"3.0.0","study":{"id":"S1"}}` {
+			t.Errorf("redriven body = %s", got)
+		}
+	})
+
+	for _, transform := range []string{"exit 1", "echo not json"} {
+		t.Run("failing transform "+transform, func(t *testing.T) {
+			a, deadLetters, queue := newApp(t, deadLettered(t, "S1", dlq.MalformedPayload), deadLettered(t, "S2", dlq.MalformedPayload))
+			if err := a.redrive(ctx, filter{}, transform, false); err == nil {
+				t.Error("redrive succeeded")
+			}
+			if len(queue.Messages()) != 0 {
+				t.Error("a message that failed to transform was sent")
+			}
+			if got := visible(t, deadLetters); !slices.Equal(got, []string{"S1", "S2"}) {
+				t.Errorf("left on the dead letter queue: %v, want both", got)
+			}
+		})
+	}
+}
+
+func TestPrepareByReference(t *testing.T) {
+	ctx := context.Background()
+	large := fmt.Sprintf(`{"study":{"id":"S1"},"pad":%q}`, strings.Repeat("x", claimcheck.MaxInlineSize))
+
+	tests := []struct {
+		name       string
+		stored     string
+		attributes map[string]string
+		wantInline bool
+	}{
+		{"fits inline again", `{"study":{"id":"S1"}}`, map[string]string{submission.MessageAttribute: "sub-1"}, true},
+		{"still too large", large, map[string]string{submission.MessageAttribute: "sub-1"}, false},
+		{"inline and too large, without attributes", large, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payloads := memoryPayloads{"s3://payloads/old": []byte(tt.stored)}
+			a := &app{payloads: payloads}
+			m := dlq.Message{ID: "m-1", Body: "s3://payloads/old", Attributes: tt.attributes}
+			if tt.attributes != nil {
+				m.Attributes[claimcheck.MessageAttribute] = "s3://payloads/old"
+			} else {
+				m.Body = tt.stored
+			}
+
+			out, err := a.prepare(ctx, m, "cat")
+			if err != nil {
+				t.Fatalf("prepare: %v", err)
+			}
+			location := out.Attributes[claimcheck.MessageAttribute]
+			if tt.wantInline {
+				if location != "" || out.Body != tt.stored {
+					t.Errorf("prepared message = %+v, want the payload inline", out)
+				}
+				return
+			}
+			if location == "" || out.Body != location || !bytes.Equal(payloads[location], []byte(tt.stored)) {
+				t.Errorf("prepared message = location %q, want the payload stored again", location)
+			}
+		})
+	}
+
+	a := &app{payloads: memoryPayloads{}}
+	m := dlq.Message{ID: "m-1", Attributes: map[string]string{claimcheck.MessageAttribute: "s3://payloads/gone"}}
+	if _, err := a.prepare(ctx, m, "cat"); !errors.Is(err, claimcheck.ErrNotFound) {
+		t.Errorf("prepare of a missing payload = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"truncated", 5, "trunc..."},
+		{"héllo", 2, "h..."}, // é is two bytes; cutting after its first is backed off
+		{"héllo", 3, "hé..."},
+		{"日本語", 4, "日..."},
+		{"日本語", 2, "..."},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
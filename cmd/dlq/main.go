@@ -0,0 +1,191 @@
+// Command dlq inspects DataIngestionDLQ and redrives its messages to
+// DataIngestionQueue:
+//
+//	dlq list    [filters]                     one line per message
+//	dlq peek    [filters]                     messages in full, one by default
+//	dlq export  [filters] -dir <dir>          one JSON file per message
+//	dlq redrive [filters] [-transform <cmd>]  send back and delete
+//
+// Every command takes -study-id, -error-code, -message-id and -max to
+// select messages; see internal/dlq for the error envelope they match.
+// The queues are read from DLQ_URL and QUEUE_URL, or -dlq-url and
+// -queue-url:
+//
+//	DLQ_URL=<dlq url> QUEUE_URL=<queue url> go run ./cmd/dlq redrive -error-code GRAPH_UNAVAILABLE
+//
+// -transform runs a shell command with the payload on stdin and sends its
+// stdout instead, e.g. -transform "jq -c '.usdmVersion = \"3.0.0\"'". A
+// payload sent by reference is read from, and if still too large stored
+// back to, the payload bucket named by PAYLOAD_BUCKET.
+//
+// Messages are received with a visibility timeout while a command runs, so
+// the processor cannot pick up a redriven message twice; whatever is not
+// deleted is made visible again before the command exits.
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/claimcheck"
+	"github.com/ankit-lilly/dtd-go-backend/internal/dlq"
+	"github.com/ankit-lilly/dtd-go-backend/internal/intake"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// payloadStore reads and writes payloads sent by reference; see
+// claimcheck.Store.
+type payloadStore interface {
+	Get(ctx context.Context, location string) ([]byte, error)
+	Put(ctx context.Context, submissionID string, payload []byte) (string, error)
+}
+
+// app is what the commands run against; main wires it to AWS.
+type app struct {
+	deadLetters dlq.Queue
+	queue       dlq.Queue
+	payloads    payloadStore
+	visibility  time.Duration
+	out         io.Writer
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+	}
+	command, args := os.Args[1], os.Args[2:]
+
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	dlqURL := fs.String("dlq-url", os.Getenv(dlq.URLEnv), "URL of the dead letter queue")
+	queueURL := fs.String("queue-url", os.Getenv(intake.QueueURLEnv), "URL of the queue to redrive to")
+	visibility := fs.Duration("visibility", 5*time.Minute, "how long received messages stay hidden from the processor")
+	f := filterFlags(fs)
+
+	var run func(a *app, ctx context.Context, f filter) error
+	switch command {
+	case "list":
+		run = (*app).list
+	case "peek":
+		f.max = 1
+		run = (*app).peek
+	case "export":
+		dir := fs.String("dir", "dlq-export", "directory to write the messages to")
+		run = func(a *app, ctx context.Context, f filter) error { return a.export(ctx, f, *dir) }
+	case "redrive":
+		transform := fs.String("transform", "", "shell command to rewrite each payload, from stdin to stdout")
+		dryRun := fs.Bool("dry-run", false, "print what would be redriven without sending or deleting anything")
+		run = func(a *app, ctx context.Context, f filter) error { return a.redrive(ctx, f, *transform, *dryRun) }
+	default:
+		usage()
+	}
+	fs.Parse(args)
+
+	if *dlqURL == "" {
+		log.Fatalf("the dead letter queue URL is required, set %s or -dlq-url", dlq.URLEnv)
+	}
+	if command == "redrive" && *queueURL == "" {
+		log.Fatalf("the queue URL is required, set %s or -queue-url", intake.QueueURLEnv)
+	}
+
+	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	a := &app{
+		deadLetters: dlq.NewSQSQueue(cfg, *dlqURL),
+		queue:       dlq.NewSQSQueue(cfg, *queueURL),
+		payloads:    claimcheck.NewStore(cfg, os.Getenv(claimcheck.BucketEnv)),
+		visibility:  *visibility,
+		out:         os.Stdout,
+	}
+	if err := run(a, ctx, *f); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	log.Fatal("usage: dlq list|peek|export|redrive [flags], see -h of each command")
+}
+
+// filter selects dead-lettered messages. Empty fields match everything.
+type filter struct {
+	studyID    string
+	code       string
+	messageIDs map[string]bool
+	max        int
+}
+
+func filterFlags(fs *flag.FlagSet) *filter {
+	f := &filter{}
+	fs.StringVar(&f.studyID, "study-id", "", "only messages for this study")
+	fs.StringVar(&f.code, "error-code", "", "only messages dead-lettered with this error code, e.g. MALFORMED_PAYLOAD")
+	fs.Func("message-id", "only these messages, comma separated", func(ids string) error {
+		f.messageIDs = make(map[string]bool)
+		for _, id := range strings.Split(ids, ",") {
+			f.messageIDs[strings.TrimSpace(id)] = true
+		}
+		return nil
+	})
+	fs.IntVar(&f.max, "max", 0, "at most this many messages, 0 for all")
+	return f
+}
+
+func (f filter) match(m dlq.Message) bool {
+	if f.messageIDs != nil && !f.messageIDs[m.ID] {
+		return false
+	}
+	if f.studyID != "" && m.StudyID() != f.studyID {
+		return false
+	}
+	if f.code != "" {
+		envelope, err := m.Envelope()
+		if err != nil || envelope == nil || string(envelope.Code) != f.code {
+			return false
+		}
+	}
+	return true
+}
+
+// scan receives the messages on the dead letter queue until it is empty
+// or f.max match, and returns the matches. The caller must delete or
+// release each of them.
+func (a *app) scan(ctx context.Context, f filter) ([]dlq.Message, error) {
+	var matches, others []dlq.Message
+	defer func() { a.release(ctx, others) }()
+
+	for f.max == 0 || len(matches) < f.max {
+		received, err := a.deadLetters.Receive(ctx, dlq.MaxReceive, a.visibility)
+		if err != nil {
+			a.release(ctx, matches)
+			return nil, err
+		}
+		if len(received) == 0 {
+			break
+		}
+		for _, m := range received {
+			if f.match(m) && (f.max == 0 || len(matches) < f.max) {
+				matches = append(matches, m)
+			} else {
+				others = append(others, m)
+			}
+		}
+	}
+	return matches, nil
+}
+
+func (a *app) release(ctx context.Context, messages []dlq.Message) {
+	for _, m := range messages {
+		if err := a.deadLetters.Release(ctx, m); err != nil {
+			log.Printf("Failed to release message %s, it becomes visible again after %s: %v", m.ID, a.visibility, err)
+		}
+	}
+}
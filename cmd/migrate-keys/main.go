@@ -0,0 +1,98 @@
+// Command migrate-keys moves a graph written before nodes were keyed by
+// study onto study-qualified keys; see graphschema.KeyMigrationQueries.
+// It connects to NEPTUNE_ENDPOINT, so run it from inside the VPC:
+//
+//	NEPTUNE_ENDPOINT=<cluster endpoint> go run ./cmd/migrate-keys
+//
+// Each study is migrated in its own transaction, which also deletes the
+// legacy nodes it was copied from unless -keep-legacy is set, and the run
+// can be repeated until it succeeds.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/internal/neptunedb/cypher"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "print the migration queries without running them")
+	keepLegacy := flag.Bool("keep-legacy", false, "do not delete the legacy nodes after migrating")
+	flag.Parse()
+
+	schema := graphschema.USDM
+	queries := schema.KeyMigrationQueries()
+	if !*keepLegacy {
+		queries = append(queries, schema.LegacyCleanupQueries()...)
+	}
+
+	if *dryRun {
+		for _, q := range queries {
+			fmt.Printf("%s;\n\n", q)
+		}
+		return
+	}
+
+	ctx := context.Background()
+	defer cypher.CloseDriver(ctx)
+
+	studyIDs, err := legacyStudies(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list studies: %v", err)
+	}
+	log.Printf("Migrating %d studies", len(studyIDs))
+
+	for _, studyID := range studyIDs {
+		if err := migrateStudy(ctx, queries, studyID); err != nil {
+			log.Fatalf("Failed to migrate study %s: %v", studyID, err)
+		}
+		log.Printf("Migrated study %s", studyID)
+	}
+}
+
+// legacyStudies returns the ids of every study. Migrating a study that is
+// already keyed finds no legacy nodes below it and changes nothing.
+func legacyStudies(ctx context.Context) ([]string, error) {
+	records, err := cypher.ExecuteReadQuery(ctx, fmt.Sprintf(
+		"MATCH (s:%s) WHERE s.%s IS NULL RETURN s.id AS id", graphschema.USDM.Root, graphschema.SnapshotOfProperty,
+	), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		id, _, err := neo4j.GetRecordValue[string](record, "id")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read study id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func migrateStudy(ctx context.Context, queries []string, studyID string) error {
+	session := cypher.GetDriver().NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	params := map[string]any{"studyId": studyID}
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		for _, q := range queries {
+			result, err := tx.Run(ctx, q, params)
+			if err != nil {
+				return nil, fmt.Errorf("error executing query part: %w. Query was: %s", err, q)
+			}
+			if _, err := result.Consume(ctx); err != nil {
+				return nil, fmt.Errorf("error from result: %w. Query was: %s", err, q)
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
@@ -0,0 +1,135 @@
+// Package claimcheck carries SDR payloads too large for an SQS message.
+// The sender stores the payload in the payload bucket and sends its
+// location instead, as the message body and in the MessageAttribute. The
+// processor reads the payload back from that location before parsing it.
+package claimcheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// BucketEnv is the environment variable holding the payload bucket
+	// name.
+	BucketEnv = "PAYLOAD_BUCKET"
+
+	// MessageAttribute is the SQS message attribute holding the s3://
+	// location of a payload that was not sent inline.
+	MessageAttribute = "PayloadLocation"
+
+	// MaxInlineSize is the largest body sent inline. SQS allows 256 KiB
+	// for the body and attributes together; the rest is left for the
+	// attributes.
+	MaxInlineSize = 250 * 1024
+
+	// KeyPrefix is where payloads are stored in the bucket. The bucket's
+	// lifecycle rule expires objects under it.
+	KeyPrefix = "payloads/"
+)
+
+// ErrNotFound is returned by Get when nothing is stored at the location,
+// e.g. because the payload expired.
+var ErrNotFound = errors.New("payload not found")
+
+// Store puts payloads in a bucket and gets them from any location it
+// returned.
+type Store struct {
+	client *s3.Client
+	bucket string
+}
+
+// NewStore returns a Store that puts payloads in bucket. A Store that only
+// gets payloads can be created with an empty bucket.
+func NewStore(cfg aws.Config, bucket string) *Store {
+	return &Store{client: s3.NewFromConfig(cfg), bucket: bucket}
+}
+
+// Put stores the payload of a submission and returns its s3:// location.
+func (s *Store) Put(ctx context.Context, submissionID string, payload []byte) (string, error) {
+	if s == nil || s.bucket == "" {
+		return "", fmt.Errorf("no payload bucket configured, set %s", BucketEnv)
+	}
+
+	key := KeyPrefix + submissionID + ".json"
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(payload),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to store payload of submission %s: %w", submissionID, err)
+	}
+	return "s3://" + s.bucket + "/" + key, nil
+}
+
+// Get reads the payload stored at location.
+func (s *Store) Get(ctx context.Context, location string) ([]byte, error) {
+	bucket, key, err := ParseLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	var missing *types.NoSuchKey
+	if errors.As(err, &missing) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, location)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payload %s: %w", location, err)
+	}
+	defer object.Body.Close()
+
+	payload, err := io.ReadAll(object.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload %s: %w", location, err)
+	}
+	return payload, nil
+}
+
+// Resolve returns the payload of an SQS message: its body, or the object
+// its MessageAttribute points at.
+func (s *Store) Resolve(ctx context.Context, message events.SQSMessage) (string, error) {
+	location := LocationOf(message)
+	if location == "" {
+		return message.Body, nil
+	}
+	payload, err := s.Get(ctx, location)
+	if err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
+
+// LocationOf returns the payload location a message carries, or "" if its
+// body is the payload.
+func LocationOf(message events.SQSMessage) string {
+	attr, ok := message.MessageAttributes[MessageAttribute]
+	if !ok || attr.StringValue == nil {
+		return ""
+	}
+	return *attr.StringValue
+}
+
+// ParseLocation splits an s3://bucket/key location.
+func ParseLocation(location string) (bucket, key string, err error) {
+	rest, ok := strings.CutPrefix(location, "s3://")
+	bucket, key, found := strings.Cut(rest, "/")
+	if !ok || !found || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid payload location %q", location)
+	}
+	return bucket, key, nil
+}
@@ -0,0 +1,148 @@
+// Package dlq describes why an SDR message ended up in DataIngestionDLQ.
+// sdrProcessor moves a message it can never process to the dead letter
+// queue itself, with an Envelope in EnvelopeAttribute. The original body
+// and attributes are kept, so cmd/dlq can redrive the message as it was
+// sent.
+package dlq
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	// URLEnv is the environment variable holding the DataIngestionDLQ URL.
+	URLEnv = "DLQ_URL"
+
+	// EnvelopeAttribute is the message attribute holding the JSON
+	// Envelope.
+	EnvelopeAttribute = "ErrorEnvelope"
+)
+
+// Stage is the step of processing a message failed at.
+type Stage string
+
+const (
+	Resolve  Stage = "resolve"
+	Parse    Stage = "parse"
+	Validate Stage = "validate"
+	Write    Stage = "write"
+)
+
+// Code says why a message failed.
+type Code string
+
+const (
+	// PayloadUnavailable: the payload sent by reference could not be read.
+	PayloadUnavailable Code = "PAYLOAD_UNAVAILABLE"
+	// MalformedPayload: the body is not JSON or does not have the shape
+	// of an SDR payload.
+	MalformedPayload Code = "MALFORMED_PAYLOAD"
+	// InvalidPayload: the payload could not be decoded for its
+	// usdmVersion.
+	InvalidPayload Code = "INVALID_PAYLOAD"
+	// ValidationFailed: the payload violates the USDM schema.
+	ValidationFailed Code = "VALIDATION_FAILED"
+	// GraphUnavailable: Neptune kept failing with errors worth retrying.
+	GraphUnavailable Code = "GRAPH_UNAVAILABLE"
+	// GraphWriteFailed: Neptune rejected the write.
+	GraphWriteFailed Code = "GRAPH_WRITE_FAILED"
+)
+
+// Envelope is the error record a dead-lettered message carries.
+// ReceiveCount is how often the message had been received when it was
+// given up on; Transient says whether the last error was worth retrying.
+type Envelope struct {
+	MessageID    string    `json:"messageId"`
+	SubmissionID string    `json:"submissionId,omitempty"`
+	StudyID      string    `json:"studyId,omitempty"`
+	Stage        Stage     `json:"stage"`
+	Code         Code      `json:"errorCode"`
+	Error        string    `json:"error"`
+	Transient    bool      `json:"transient"`
+	ReceiveCount int       `json:"receiveCount"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Message is a message on an ingestion queue. Only string attributes are
+// kept, which are the only kind the pipeline sends.
+type Message struct {
+	ID            string            `json:"messageId"`
+	ReceiptHandle string            `json:"-"`
+	Body          string            `json:"body"`
+	Attributes    map[string]string `json:"attributes,omitempty"`
+}
+
+// FromEvent returns the message a Lambda received from SQS.
+func FromEvent(message events.SQSMessage) Message {
+	m := Message{
+		ID:            message.MessageId,
+		ReceiptHandle: message.ReceiptHandle,
+		Body:          message.Body,
+		Attributes:    make(map[string]string, len(message.MessageAttributes)),
+	}
+	for name, attr := range message.MessageAttributes {
+		if attr.StringValue != nil {
+			m.Attributes[name] = *attr.StringValue
+		}
+	}
+	return m
+}
+
+// WithEnvelope returns a copy of m carrying envelope.
+func (m Message) WithEnvelope(envelope Envelope) (Message, error) {
+	encoded, err := json.Marshal(envelope)
+	if err != nil {
+		return m, fmt.Errorf("failed to marshal error envelope: %w", err)
+	}
+	m.Attributes = maps.Clone(m.Attributes)
+	if m.Attributes == nil {
+		m.Attributes = map[string]string{}
+	}
+	m.Attributes[EnvelopeAttribute] = string(encoded)
+	return m, nil
+}
+
+// WithoutEnvelope returns a copy of m as it was before it was
+// dead-lettered.
+func (m Message) WithoutEnvelope() Message {
+	m.Attributes = maps.Clone(m.Attributes)
+	delete(m.Attributes, EnvelopeAttribute)
+	return m
+}
+
+// Envelope returns the error envelope of m, or nil for a message SQS moved
+// to the dead letter queue by its redrive policy.
+func (m Message) Envelope() (*Envelope, error) {
+	encoded, ok := m.Attributes[EnvelopeAttribute]
+	if !ok {
+		return nil, nil
+	}
+	var envelope Envelope
+	if err := json.Unmarshal([]byte(encoded), &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse error envelope of message %s: %w", m.ID, err)
+	}
+	return &envelope, nil
+}
+
+// StudyID returns the id of the study m carries: from its envelope, or
+// else from its body. It is empty if neither says, e.g. for a payload sent
+// by reference that failed before it was read.
+func (m Message) StudyID() string {
+	if envelope, err := m.Envelope(); err == nil && envelope != nil && envelope.StudyID != "" {
+		return envelope.StudyID
+	}
+	var payload struct {
+		Study struct {
+			ID string `json:"id"`
+		} `json:"study"`
+	}
+	if err := json.Unmarshal([]byte(m.Body), &payload); err != nil {
+		return ""
+	}
+	return payload.Study.ID
+}
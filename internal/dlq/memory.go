@@ -0,0 +1,98 @@
+package dlq
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// MemoryQueue is a Queue kept in a slice, with SQS's visibility timeout
+// and receipt handles, so cmd/dlq's commands can run without SQS.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	messages []*queued
+	next     int
+	now      func() time.Time
+}
+
+type queued struct {
+	message        Message
+	invisibleUntil time.Time
+}
+
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{now: time.Now}
+}
+
+// Messages returns every message on the queue, visible or not.
+func (q *MemoryQueue) Messages() []Message {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	messages := make([]Message, len(q.messages))
+	for i, m := range q.messages {
+		messages[i] = m.message
+	}
+	return messages
+}
+
+func (q *MemoryQueue) Receive(_ context.Context, max int, visibility time.Duration) ([]Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	var received []Message
+	for _, m := range q.messages {
+		if len(received) == min(max, MaxReceive) {
+			break
+		}
+		if now.Before(m.invisibleUntil) {
+			continue
+		}
+		q.next++
+		m.message.ReceiptHandle = fmt.Sprintf("receipt-%d", q.next)
+		m.invisibleUntil = now.Add(visibility)
+		received = append(received, m.message)
+	}
+	return received, nil
+}
+
+func (q *MemoryQueue) Send(_ context.Context, message Message) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.next++
+	message.ID = fmt.Sprintf("message-%d", q.next)
+	message.ReceiptHandle = ""
+	message.Attributes = maps.Clone(message.Attributes)
+	q.messages = append(q.messages, &queued{message: message})
+	return message.ID, nil
+}
+
+func (q *MemoryQueue) Delete(_ context.Context, message Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, m := range q.messages {
+		if m.message.ReceiptHandle == message.ReceiptHandle && message.ReceiptHandle != "" {
+			q.messages = append(q.messages[:i], q.messages[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("message %s is not on the queue", message.ID)
+}
+
+func (q *MemoryQueue) Release(_ context.Context, message Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, m := range q.messages {
+		if m.message.ReceiptHandle == message.ReceiptHandle && message.ReceiptHandle != "" {
+			m.invisibleUntil = time.Time{}
+			return nil
+		}
+	}
+	return fmt.Errorf("message %s is not on the queue", message.ID)
+}
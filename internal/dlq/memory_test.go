@@ -0,0 +1,115 @@
+package dlq
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryQueue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	q := NewMemoryQueue()
+	q.now = func() time.Time { return now }
+
+	for _, body := range []string{"a", "b", "c"} {
+		if _, err := q.Send(ctx, Message{Body: body, ReceiptHandle: "stale", Attributes: map[string]string{"k": body}}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	first, _ := q.Receive(ctx, 2, time.Minute)
+	if len(first) != 2 || first[0].Body != "a" || first[1].Body != "b" {
+		t.Fatalf("first Receive = %v, want a and b", first)
+	}
+	if first[0].ReceiptHandle == "" || first[0].ReceiptHandle == "stale" {
+		t.Errorf("received message has receipt handle %q", first[0].ReceiptHandle)
+	}
+
+	// Received messages stay hidden for the visibility timeout.
+	second, _ := q.Receive(ctx, 10, time.Minute)
+	if len(second) != 1 || second[0].Body != "c" {
+		t.Fatalf("second Receive = %v, want c", second)
+	}
+	if third, _ := q.Receive(ctx, 10, time.Minute); len(third) != 0 {
+		t.Fatalf("third Receive = %v, want nothing visible", third)
+	}
+
+	if err := q.Delete(ctx, first[0]); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := q.Delete(ctx, first[0]); err == nil {
+		t.Error("deleting a message twice succeeded")
+	}
+	if err := q.Release(ctx, first[1]); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if again, _ := q.Receive(ctx, 10, time.Minute); len(again) != 1 || again[0].Body != "b" {
+		t.Fatalf("Receive after Release = %v, want b", again)
+	}
+
+	// c becomes visible again once its timeout passes, with a new handle.
+	now = now.Add(2 * time.Minute)
+	late, _ := q.Receive(ctx, 10, time.Minute)
+	if len(late) != 2 {
+		t.Fatalf("Receive after the timeout = %v, want b and c", late)
+	}
+	if err := q.Delete(ctx, second[0]); err == nil {
+		t.Error("deleting with an expired receipt handle succeeded")
+	}
+
+	if got := len(q.Messages()); got != 2 {
+		t.Errorf("Messages holds %d, want 2", got)
+	}
+}
+
+func TestMemoryQueueSendCopiesAttributes(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+	attributes := map[string]string{"k": "v"}
+	q.Send(ctx, Message{Body: "a", Attributes: attributes})
+	attributes["k"] = "changed"
+
+	if got := q.Messages()[0].Attributes["k"]; got != "v" {
+		t.Errorf("queued attribute = %q, want v", got)
+	}
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	m := Message{ID: "m-1", Body: `{"study":{"id":"S-body"}}`, Attributes: map[string]string{"SubmissionId": "sub-1"}}
+	if m.StudyID() != "S-body" {
+		t.Errorf("StudyID without an envelope = %q, want S-body", m.StudyID())
+	}
+	if e, err := m.Envelope(); e != nil || err != nil {
+		t.Errorf("Envelope of a message without one = %v, %v", e, err)
+	}
+
+	envelope := Envelope{MessageID: "m-1", StudyID: "S-envelope", Stage: Write, Code: GraphUnavailable, Transient: true, ReceiveCount: 3}
+	dead, err := m.WithEnvelope(envelope)
+	if err != nil {
+		t.Fatalf("WithEnvelope: %v", err)
+	}
+	if _, ok := m.Attributes[EnvelopeAttribute]; ok {
+		t.Error("WithEnvelope changed the original message")
+	}
+	got, err := dead.Envelope()
+	if err != nil || got == nil || *got != envelope {
+		t.Errorf("Envelope = %+v, %v, want %+v", got, err, envelope)
+	}
+	if dead.StudyID() != "S-envelope" {
+		t.Errorf("StudyID = %q, want the envelope's", dead.StudyID())
+	}
+
+	back := dead.WithoutEnvelope()
+	if _, ok := back.Attributes[EnvelopeAttribute]; ok || back.Attributes["SubmissionId"] != "sub-1" {
+		t.Errorf("WithoutEnvelope attributes = %v", back.Attributes)
+	}
+	if _, ok := dead.Attributes[EnvelopeAttribute]; !ok {
+		t.Error("WithoutEnvelope changed the dead-lettered message")
+	}
+
+	broken := Message{ID: "m-2", Attributes: map[string]string{EnvelopeAttribute: "{"}}
+	if _, err := broken.Envelope(); err == nil {
+		t.Error("a malformed envelope parsed")
+	}
+}
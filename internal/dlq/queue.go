@@ -0,0 +1,111 @@
+package dlq
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// MaxReceive is the most messages one Receive returns.
+const MaxReceive = 10
+
+// Queue is the access to an ingestion queue that dead-lettering and
+// redriving need. A received message stays invisible to other receivers
+// for the visibility timeout, until it is deleted or released.
+type Queue interface {
+	Receive(ctx context.Context, max int, visibility time.Duration) ([]Message, error)
+	Send(ctx context.Context, message Message) (messageID string, err error)
+	Delete(ctx context.Context, message Message) error
+	Release(ctx context.Context, message Message) error
+}
+
+// SQSQueue is a Queue backed by an SQS queue.
+type SQSQueue struct {
+	client *sqs.Client
+	url    string
+}
+
+func NewSQSQueue(cfg aws.Config, url string) *SQSQueue {
+	return &SQSQueue{client: sqs.NewFromConfig(cfg), url: url}
+}
+
+// Receive waits up to a second for messages, so that an empty result
+// means the queue has no visible messages rather than that the sampled
+// servers had none.
+func (q *SQSQueue) Receive(ctx context.Context, max int, visibility time.Duration) ([]Message, error) {
+	output, err := q.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String(q.url),
+		MaxNumberOfMessages:   int32(min(max, MaxReceive)),
+		VisibilityTimeout:     int32(visibility / time.Second),
+		WaitTimeSeconds:       1,
+		MessageAttributeNames: []string{"All"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive messages: %w", err)
+	}
+
+	messages := make([]Message, 0, len(output.Messages))
+	for _, m := range output.Messages {
+		message := Message{
+			ID:            aws.ToString(m.MessageId),
+			ReceiptHandle: aws.ToString(m.ReceiptHandle),
+			Body:          aws.ToString(m.Body),
+			Attributes:    make(map[string]string, len(m.MessageAttributes)),
+		}
+		for name, attr := range m.MessageAttributes {
+			if attr.StringValue != nil {
+				message.Attributes[name] = *attr.StringValue
+			}
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func (q *SQSQueue) Send(ctx context.Context, message Message) (string, error) {
+	attributes := make(map[string]types.MessageAttributeValue, len(message.Attributes))
+	for name, value := range message.Attributes {
+		attributes[name] = types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(value),
+		}
+	}
+
+	output, err := q.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          aws.String(q.url),
+		MessageBody:       aws.String(message.Body),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to send message %s: %w", message.ID, err)
+	}
+	return aws.ToString(output.MessageId), nil
+}
+
+func (q *SQSQueue) Delete(ctx context.Context, message Message) error {
+	_, err := q.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(q.url),
+		ReceiptHandle: aws.String(message.ReceiptHandle),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete message %s: %w", message.ID, err)
+	}
+	return nil
+}
+
+// Release makes a received message visible again at once.
+func (q *SQSQueue) Release(ctx context.Context, message Message) error {
+	_, err := q.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(q.url),
+		ReceiptHandle:     aws.String(message.ReceiptHandle),
+		VisibilityTimeout: 0,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release message %s: %w", message.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+// Package fhir maps a stored study onto HL7 FHIR resources for clinical
+// systems that do not read USDM. Every study version becomes a
+// ResearchStudy, every study design a PlanDefinition of its schedule and
+// every activity an ActivityDefinition the schedule points at; the
+// organizations the study names become Organization resources. They are
+// returned together as a collection Bundle, in FHIR R4 or R5.
+package fhir
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// Release is the FHIR version the resources are written in.
+type Release string
+
+const (
+	R4 Release = "R4"
+	R5 Release = "R5"
+)
+
+// ParseRelease reads a release as "R4" or "R5", in any case. An empty
+// release is R4, which most clinical systems still speak.
+func ParseRelease(s string) (Release, error) {
+	switch Release(strings.ToUpper(s)) {
+	case "", R4:
+		return R4, nil
+	case R5:
+		return R5, nil
+	default:
+		return "", fmt.Errorf("unsupported FHIR release %q, supported releases are R4 and R5", s)
+	}
+}
+
+// Resource is a FHIR resource as its JSON object.
+type Resource map[string]any
+
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Type         string        `json:"type"`
+	Timestamp    string        `json:"timestamp"`
+	Entry        []BundleEntry `json:"entry"`
+}
+
+type BundleEntry struct {
+	FullURL  string   `json:"fullUrl"`
+	Resource Resource `json:"resource"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Version string `json:"version,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Reference struct {
+	Reference string `json:"reference"`
+	Display   string `json:"display,omitempty"`
+}
+
+// Export maps study onto a Bundle of FHIR resources in release.
+func Export(study models.Study, release Release) Bundle {
+	b := &builder{study: study, release: release}
+	for _, version := range study.Versions {
+		if version != nil {
+			b.version(version)
+		}
+	}
+	return Bundle{
+		ResourceType: "Bundle",
+		Type:         "collection",
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Entry:        b.entries,
+	}
+}
+
+// builder collects the entries of a bundle. Resources that several
+// versions or designs share, such as an organization or an activity, are
+// added once.
+type builder struct {
+	study   models.Study
+	release Release
+	entries []BundleEntry
+	added   map[string]bool
+}
+
+// add adds resource unless a resource of its type and id is in the bundle
+// already, and returns the URL to reference it by.
+func (b *builder) add(resource Resource) string {
+	resourceType, _ := resource["resourceType"].(string)
+	id, _ := resource["id"].(string)
+	url := b.url(resourceType, id)
+	if b.added == nil {
+		b.added = make(map[string]bool)
+	}
+	if !b.added[url] {
+		b.added[url] = true
+		b.entries = append(b.entries, BundleEntry{FullURL: url, Resource: resource})
+	}
+	return url
+}
+
+// url is the fullUrl of a resource in the bundle: a name-based UUID of the
+// study, resource type and id, so exporting a study twice yields the same
+// URLs and resources in different studies do not collide.
+func (b *builder) url(resourceType, id string) string {
+	sum := sha1.Sum([]byte(b.study.ID + "/" + resourceType + "/" + id))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+func (b *builder) ref(resourceType, id, display string) Reference {
+	return Reference{Reference: b.url(resourceType, id), Display: display}
+}
+
+var invalidIDChars = regexp.MustCompile(`[^A-Za-z0-9\-.]`)
+
+// resourceID turns a USDM id into a FHIR id, which allows letters, digits,
+// "-" and "." and at most 64 characters.
+func resourceID(id string) string {
+	fhirID := invalidIDChars.ReplaceAllString(id, "-")
+	if len(fhirID) > 64 {
+		sum := sha1.Sum([]byte(id))
+		fhirID = fmt.Sprintf("%s-%x", fhirID[:47], sum[:8])
+	}
+	return fhirID
+}
+
+func concept(c *models.Code) *CodeableConcept {
+	if c == nil {
+		return nil
+	}
+	return &CodeableConcept{
+		Coding: []Coding{{System: c.CodeSystem, Version: c.CodeSystemVersion, Code: c.Code, Display: c.Decode}},
+		Text:   c.Decode,
+	}
+}
+
+func aliasConcept(c *models.AliasCode) *CodeableConcept {
+	if c == nil || c.StandardCode == nil {
+		return nil
+	}
+	cc := concept(c.StandardCode)
+	for _, alias := range c.StandardCodeAliases {
+		if alias != nil {
+			cc.Coding = append(cc.Coding, concept(alias).Coding...)
+		}
+	}
+	return cc
+}
+
+// text returns the first of values that is set.
+func text(values ...*string) string {
+	for _, v := range values {
+		if v != nil && *v != "" {
+			return *v
+		}
+	}
+	return ""
+}
+
+// set adds value to r under key unless it is empty: an empty string, a nil
+// pointer or an empty list.
+func set(r Resource, key string, value any) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return
+	case reflect.String, reflect.Slice, reflect.Map:
+		if v.Len() == 0 {
+			return
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+	}
+	r[key] = value
+}
@@ -0,0 +1,261 @@
+package fhir
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+const fixture = `{"id":"S1","description":"A study","versions":[{"id":"SV_1","versionIdentifier":"2",
+	"titles":[
+		{"id":"T1","text":"Brief","type":{"id":"C1","code":"C99","codeSystem":"NCIt","decode":"Brief Study Title"}},
+		{"id":"T2","text":"Official","type":{"id":"C2","code":"C98","codeSystem":"NCIt","decode":"Official Study Title"}}],
+	"studyIdentifiers":[{"id":"SI1","text":"NCT1","scopeId":"O1"}],
+	"organizations":[{"id":"O1","name":"Acme","legalAddress":{"id":"A1","text":"1 Main St","city":"Springfield"}}],
+	"roles":[{"id":"R1","code":{"id":"C3","code":"C70793","codeSystem":"NCIt","decode":"Sponsor"},"organizationIds":["O1"]}],
+	"studyDesigns":[{"id":"SD1","name":"Design",
+		"arms":[{"id":"ARM1","name":"Treatment"}],
+		"activities":[{"id":"ACT2","name":"Labs","previousId":"ACT1"},{"id":"ACT1","name":"Vitals","nextId":"ACT2"}],
+		"encounters":[{"id":"E1","label":"Screening","scheduledAtId":"TM1",
+			"type":{"id":"C4","code":"C7652","codeSystem":"NCIt","decode":"Visit"}}],
+		"scheduleTimelines":[{"id":"TL1","mainTimeline":true,
+			"timings":[{"id":"TM1","value":"-P7D"}],
+			"instances":[{"id":"I1","encounterId":"E1","activityIds":["ACT2","ACT1","ACT2"]}]}]}]}]}`
+
+func exportFixture(t *testing.T, studyID string, release Release) (Bundle, map[string]map[string]any) {
+	t.Helper()
+	var study models.Study
+	if err := json.Unmarshal([]byte(fixture), &study); err != nil {
+		t.Fatal(err)
+	}
+	study.ID = studyID
+	bundle := Export(study, release)
+
+	// Resources are compared as the JSON they are served as.
+	raw, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Entry []struct {
+			FullURL  string         `json:"fullUrl"`
+			Resource map[string]any `json:"resource"`
+		} `json:"entry"`
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	resources := make(map[string]map[string]any)
+	for _, e := range decoded.Entry {
+		resources[e.Resource["resourceType"].(string)+"/"+e.Resource["id"].(string)] = e.Resource
+	}
+	return bundle, resources
+}
+
+// fullURLs returns the fullUrl of each resource in bundle by type and id.
+func fullURLs(bundle Bundle) map[string]string {
+	urls := make(map[string]string)
+	for _, e := range bundle.Entry {
+		urls[e.Resource["resourceType"].(string)+"/"+e.Resource["id"].(string)] = e.FullURL
+	}
+	return urls
+}
+
+// decode returns the JSON form of v.
+func decode(t *testing.T, v string) any {
+	t.Helper()
+	var out any
+	if err := json.Unmarshal([]byte(v), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestExportResearchStudy(t *testing.T) {
+	tests := []struct {
+		release Release
+		want    map[string]string
+		absent  []string
+	}{
+		{
+			release: R4,
+			want: map[string]string{
+				"title": `"Official"`,
+				"arm":   `[{"name":"Treatment"}]`,
+			},
+			absent: []string{"label", "comparisonGroup", "associatedParty", "version"},
+		},
+		{
+			release: R5,
+			want: map[string]string{
+				"title":   `"Official"`,
+				"version": `"2"`,
+				"label": `[
+					{"value":"Brief","type":{"coding":[{"system":"NCIt","code":"C99","display":"Brief Study Title"}],"text":"Brief Study Title"}},
+					{"value":"Official","type":{"coding":[{"system":"NCIt","code":"C98","display":"Official Study Title"}],"text":"Official Study Title"}}]`,
+				"comparisonGroup": `[{"name":"Treatment","linkId":"ARM1"}]`,
+			},
+			absent: []string{"arm", "sponsor"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.release), func(t *testing.T) {
+			bundle, resources := exportFixture(t, "S1", tt.release)
+			rs := resources["ResearchStudy/SV-1"]
+			if rs == nil {
+				t.Fatalf("no ResearchStudy/SV-1 in %v", resources)
+			}
+			for key, want := range tt.want {
+				if got := rs[key]; !reflect.DeepEqual(got, decode(t, want)) {
+					t.Errorf("%s = %v, want %s", key, got, want)
+				}
+			}
+			for _, key := range tt.absent {
+				if got, ok := rs[key]; ok {
+					t.Errorf("%s = %v, want none in %s", key, got, tt.release)
+				}
+			}
+
+			org := map[string]any{"reference": fullURLs(bundle)["Organization/O1"], "display": "Acme"}
+			switch tt.release {
+			case R4:
+				if !reflect.DeepEqual(rs["sponsor"], org) {
+					t.Errorf("sponsor = %v, want %v", rs["sponsor"], org)
+				}
+			case R5:
+				want := []any{map[string]any{
+					"party": org,
+					"name":  "Acme",
+					"role":  decode(t, `{"coding":[{"system":"NCIt","code":"C70793","display":"Sponsor"}],"text":"Sponsor"}`),
+				}}
+				if !reflect.DeepEqual(rs["associatedParty"], want) {
+					t.Errorf("associatedParty = %v, want %v", rs["associatedParty"], want)
+				}
+			}
+		})
+	}
+}
+
+func TestExportPlanDefinition(t *testing.T) {
+	encounterCode := `{"coding":[{"system":"NCIt","code":"C7652","display":"Visit"}],"text":"Visit"}`
+	tests := []struct {
+		release Release
+		code    string
+		linkID  bool
+	}{
+		{R4, "[" + encounterCode + "]", false},
+		{R5, encounterCode, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.release), func(t *testing.T) {
+			bundle, resources := exportFixture(t, "S1", tt.release)
+			plan := resources["PlanDefinition/SD1"]
+			if plan == nil {
+				t.Fatalf("no PlanDefinition/SD1 in %v", resources)
+			}
+			actions, _ := plan["action"].([]any)
+			if len(actions) != 1 {
+				t.Fatalf("action = %v, want one per encounter", plan["action"])
+			}
+			action := actions[0].(map[string]any)
+			if got := action["code"]; !reflect.DeepEqual(got, decode(t, tt.code)) {
+				t.Errorf("code = %v, want %s", got, tt.code)
+			}
+			if _, ok := action["linkId"]; ok != tt.linkID {
+				t.Errorf("linkId = %v, want it set %v", action["linkId"], tt.linkID)
+			}
+			wantDuration := decode(t, `{"value":-7,"unit":"d","system":"http://unitsofmeasure.org","code":"d"}`)
+			if !reflect.DeepEqual(action["timingDuration"], wantDuration) {
+				t.Errorf("timingDuration = %v, want %v", action["timingDuration"], wantDuration)
+			}
+
+			// Activities are in the order they are scheduled, each once, and
+			// point at the fullUrl of their ActivityDefinition.
+			urls := fullURLs(bundle)
+			var got []string
+			for _, step := range action["action"].([]any) {
+				got = append(got, step.(map[string]any)["definitionCanonical"].(string))
+			}
+			want := []string{urls["ActivityDefinition/ACT2"], urls["ActivityDefinition/ACT1"]}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("definitionCanonical = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestResourceID(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"Study_1", "Study-1"},
+		{"a.b-c", "a.b-c"},
+		{"x/y z#1", "x-y-z-1"},
+		{strings.Repeat("b", 64), strings.Repeat("b", 64)},
+	}
+	for _, tt := range tests {
+		if got := resourceID(tt.id); got != tt.want {
+			t.Errorf("resourceID(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+
+	got := resourceID(long)
+	if len(got) != 64 || !strings.HasPrefix(got, strings.Repeat("a", 47)+"-") {
+		t.Errorf("resourceID(%d a's) = %q, want 47 a's and a hash, 64 long", len(long), got)
+	}
+	if other := resourceID(long + "b"); other == got {
+		t.Errorf("resourceID of two long ids sharing a prefix are both %q", got)
+	}
+}
+
+func TestExportURLsAreStable(t *testing.T) {
+	uuid := regexp.MustCompile(`^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	urls := func(studyID string) []string {
+		bundle, _ := exportFixture(t, studyID, R4)
+		var out []string
+		for _, e := range bundle.Entry {
+			if !uuid.MatchString(e.FullURL) {
+				t.Errorf("fullUrl %q is not a name-based UUID", e.FullURL)
+			}
+			out = append(out, e.FullURL)
+		}
+		return out
+	}
+
+	first, again, other := urls("S1"), urls("S1"), urls("S2")
+	if !reflect.DeepEqual(first, again) {
+		t.Errorf("exporting twice gave %v and %v", first, again)
+	}
+	for _, url := range other {
+		for _, u := range first {
+			if url == u {
+				t.Errorf("%s is used by two studies", url)
+			}
+		}
+	}
+}
+
+func TestParseRelease(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Release
+		wantErr bool
+	}{
+		{"", R4, false},
+		{"r4", R4, false},
+		{"R5", R5, false},
+		{"STU3", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRelease(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseRelease(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package fhir
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/usdm"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+const planDefinitionTypeSystem = "http://terminology.hl7.org/CodeSystem/plan-definition-type"
+
+// design adds the PlanDefinition of design's schedule and the
+// ActivityDefinitions of its activities, and returns the reference to the
+// PlanDefinition.
+//
+// The plan has an action per encounter, in encounter order, holding an
+// action per activity the main timeline schedules at it. The study
+// version's conditions become applicability conditions of the actions of
+// the encounters and activities they apply to.
+func (b *builder) design(design *models.StudyDesign, conditions []*models.Conditions) Reference {
+	activities := usdm.ChainOrder(present(design.Activities), func(a *models.Activity) (string, *string, *string) {
+		return a.ID, a.PreviousID, a.NextID
+	})
+	definitions := make(map[string]string, len(activities))
+	for _, activity := range activities {
+		definitions[activity.ID] = b.activity(activity)
+	}
+
+	appliesTo := make(map[string][]Resource)
+	for _, condition := range conditions {
+		if condition == nil {
+			continue
+		}
+		expression := Resource{"language": "text/plain"}
+		set(expression, "expression", text(condition.Text, condition.Description, condition.Name))
+		set(expression, "description", text(condition.Description, condition.Label))
+		for _, id := range condition.AppliesToIds {
+			appliesTo[id] = append(appliesTo[id], Resource{"kind": "applicability", "expression": expression})
+		}
+	}
+
+	timings := make(map[string]*models.Timing)
+	scheduled := make(map[string][]string)
+	for _, timeline := range design.ScheduleTimelines {
+		if timeline == nil {
+			continue
+		}
+		for _, timing := range timeline.Timings {
+			if timing != nil {
+				timings[timing.ID] = timing
+			}
+		}
+		if !timeline.MainTimeline {
+			continue
+		}
+		for _, instance := range timeline.Instances {
+			if instance == nil || instance.EncounterID == nil {
+				continue
+			}
+			scheduled[*instance.EncounterID] = append(scheduled[*instance.EncounterID], instance.ActivityIDs...)
+		}
+	}
+
+	var actions []Resource
+	encounters := usdm.ChainOrder(present(design.Encounters), func(e *models.Encounter) (string, *string, *string) {
+		return e.ID, e.PreviousID, e.NextID
+	})
+	for _, encounter := range encounters {
+		action := b.action(encounter.ID, text(encounter.Label, encounter.Name), encounter.Description)
+		if encounter.Type != nil {
+			b.code(action, concept(&models.Code{
+				Code:              encounter.Type.Code,
+				CodeSystem:        encounter.Type.CodeSystem,
+				CodeSystemVersion: encounter.Type.CodeSystemVersion,
+				Decode:            encounter.Type.Decode,
+			}))
+		}
+		timing := encounter.ScheduledAt
+		if timing == nil && encounter.ScheduledAtID != nil {
+			timing = timings[*encounter.ScheduledAtID]
+		}
+		if timing != nil {
+			set(action, "timingDuration", duration(text(timing.Value)))
+		}
+		set(action, "condition", appliesTo[encounter.ID])
+
+		var steps []Resource
+		seen := make(map[string]bool)
+		for _, activityID := range scheduled[encounter.ID] {
+			if seen[activityID] || definitions[activityID] == "" {
+				continue
+			}
+			seen[activityID] = true
+			activity := activityByID(activities, activityID)
+			step := b.action(activity.ID, text(activity.Label, activity.Name), activity.Description)
+			step["definitionCanonical"] = definitions[activityID]
+			set(step, "condition", appliesTo[activityID])
+			steps = append(steps, step)
+		}
+		set(action, "action", steps)
+		actions = append(actions, action)
+	}
+
+	plan := Resource{
+		"resourceType": "PlanDefinition",
+		"id":           resourceID(design.ID),
+		"status":       "active",
+		"type": &CodeableConcept{
+			Coding: []Coding{{System: planDefinitionTypeSystem, Code: "clinical-protocol", Display: "Clinical Protocol"}},
+		},
+	}
+	plan["url"] = b.url("PlanDefinition", resourceID(design.ID))
+	set(plan, "name", text(design.Name))
+	set(plan, "title", text(design.Label, design.Name))
+	set(plan, "description", text(design.Description))
+	set(plan, "action", actions)
+	b.add(plan)
+	return b.ref("PlanDefinition", resourceID(design.ID), text(design.Label, design.Name))
+}
+
+// activity adds the ActivityDefinition of activity and returns its
+// canonical URL, which is also its fullUrl.
+func (b *builder) activity(activity *models.Activity) string {
+	id := resourceID(activity.ID)
+	url := b.url("ActivityDefinition", id)
+	r := Resource{
+		"resourceType": "ActivityDefinition",
+		"id":           id,
+		"url":          url,
+		"status":       "active",
+	}
+	set(r, "name", text(activity.Name))
+	set(r, "title", text(activity.Label, activity.Name))
+	set(r, "description", text(activity.Description))
+	for _, procedure := range activity.DefinedProcedures {
+		if procedure != nil && procedure.Code != nil {
+			r["code"] = concept(procedure.Code)
+			break
+		}
+	}
+	return b.add(r)
+}
+
+// action returns a PlanDefinition action for the USDM item id. R5 actions
+// carry the id as their linkId.
+func (b *builder) action(id, title string, description *string) Resource {
+	action := Resource{}
+	if b.release == R5 {
+		action["linkId"] = id
+	}
+	set(action, "title", title)
+	set(action, "description", text(description))
+	return action
+}
+
+// code sets the code of action, a list in R4 and a single concept in R5.
+func (b *builder) code(action Resource, cc *CodeableConcept) {
+	if b.release == R5 {
+		action["code"] = cc
+	} else {
+		action["code"] = []*CodeableConcept{cc}
+	}
+}
+
+// present drops the nil items of items.
+func present[T any](items []*T) []*T {
+	kept := make([]*T, 0, len(items))
+	for _, item := range items {
+		if item != nil {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+func activityByID(activities []*models.Activity, id string) *models.Activity {
+	for _, a := range activities {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+var isoDuration = regexp.MustCompile(`^(-?)P(?:(\d+)([YMWD])|T(\d+)([HMS]))$`)
+
+// durationUnits maps ISO 8601 designators to UCUM codes; M means months in
+// the date part and minutes in the time part.
+var durationUnits = map[string]string{
+	"Y": "a", "M": "mo", "W": "wk", "D": "d",
+	"TH": "h", "TM": "min", "TS": "s",
+}
+
+// duration returns the FHIR Duration of an ISO 8601 duration of a single
+// unit, such as "P7D" or "-PT2H", the form USDM timings use. Any other
+// value has no Duration.
+func duration(value string) Resource {
+	m := isoDuration.FindStringSubmatch(value)
+	if m == nil {
+		return nil
+	}
+	number, unit := m[2], m[3]
+	if number == "" {
+		number, unit = m[4], "T"+m[5]
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return nil
+	}
+	if m[1] == "-" {
+		n = -n
+	}
+	code := durationUnits[unit]
+	return Resource{"value": n, "unit": code, "system": "http://unitsofmeasure.org", "code": code}
+}
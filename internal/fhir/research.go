@@ -0,0 +1,234 @@
+package fhir
+
+import (
+	"strings"
+
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// officialTitle is the decode of the USDM title type a ResearchStudy takes
+// its title from.
+const officialTitle = "Official Study Title"
+
+// version adds the ResearchStudy of version together with the resources it
+// references.
+func (b *builder) version(version *models.StudyVersion) {
+	orgs := make(map[string]*models.Organization, len(version.Organizations))
+	for _, org := range version.Organizations {
+		if org != nil {
+			orgs[org.ID] = org
+		}
+	}
+
+	rs := Resource{
+		"resourceType": "ResearchStudy",
+		"id":           resourceID(version.ID),
+		"status":       "active",
+	}
+	b.add(rs)
+	b.titles(rs, version.Titles)
+	set(rs, "description", text(b.study.Description, version.Rationale))
+	if b.release == R5 {
+		set(rs, "version", version.VersionIdentifier)
+	}
+
+	var identifiers []Resource
+	for _, si := range version.StudyIdentifiers {
+		if si == nil {
+			continue
+		}
+		identifier := Resource{"value": si.Text}
+		if si.ScopeID != nil {
+			if org := orgs[*si.ScopeID]; org != nil {
+				identifier["assigner"] = b.ref("Organization", resourceID(org.ID), text(org.Name, org.Label))
+			}
+		}
+		identifiers = append(identifiers, identifier)
+	}
+	set(rs, "identifier", identifiers)
+
+	var conditions []*CodeableConcept
+	var objectives []Resource
+	var protocols []Reference
+	var arms []Resource
+	for _, design := range version.StudyDesigns {
+		if design == nil {
+			continue
+		}
+		if _, ok := rs["phase"]; !ok {
+			set(rs, "phase", aliasConcept(design.StudyPhase))
+		}
+		for _, indication := range design.Indications {
+			if indication != nil {
+				conditions = append(conditions, indicationConcept(indication))
+			}
+		}
+		for _, objective := range design.Objectives {
+			if objective != nil {
+				objectives = append(objectives, b.objective(objective))
+			}
+		}
+		for _, arm := range design.Arms {
+			if arm != nil {
+				arms = append(arms, b.arm(arm))
+			}
+		}
+		protocols = append(protocols, b.design(design, version.Conditions))
+	}
+	set(rs, "condition", conditions)
+	set(rs, "objective", objectives)
+	set(rs, "protocol", protocols)
+	if b.release == R5 {
+		set(rs, "comparisonGroup", arms)
+	} else {
+		set(rs, "arm", arms)
+	}
+	b.parties(rs, version.Roles, orgs)
+
+	for _, org := range version.Organizations {
+		if org != nil {
+			b.organization(org)
+		}
+	}
+}
+
+// titles sets the title of rs to the official title, or the first title if
+// there is none, and in R5 lists every title as a label.
+func (b *builder) titles(rs Resource, titles []*models.StudyTitle) {
+	var title string
+	var labels []Resource
+	for _, t := range titles {
+		if t == nil {
+			continue
+		}
+		if title == "" || t.Type != nil && t.Type.Decode == officialTitle {
+			title = t.Text
+		}
+		label := Resource{"value": t.Text}
+		set(label, "type", concept(t.Type))
+		labels = append(labels, label)
+	}
+	set(rs, "title", title)
+	if b.release == R5 {
+		set(rs, "label", labels)
+	}
+}
+
+// parties references the organizations of roles: in R5 each as an
+// associatedParty in its role, in R4 the first sponsor's as the sponsor.
+func (b *builder) parties(rs Resource, roles []*models.StudyRole, orgs map[string]*models.Organization) {
+	var parties []Resource
+	for _, role := range roles {
+		if role == nil {
+			continue
+		}
+		for _, orgID := range role.OrganizationIDs {
+			org := orgs[orgID]
+			if org == nil {
+				continue
+			}
+			ref := b.ref("Organization", resourceID(org.ID), text(org.Name, org.Label))
+			if b.release == R5 {
+				party := Resource{"party": ref}
+				set(party, "name", text(org.Name, org.Label))
+				if cc := concept(role.Code); cc != nil {
+					party["role"] = cc
+				} else {
+					party["role"] = &CodeableConcept{Text: text(role.Name, role.Label)}
+				}
+				parties = append(parties, party)
+				continue
+			}
+			isSponsor := role.Code != nil && strings.Contains(strings.ToLower(role.Code.Decode), "sponsor")
+			if _, ok := rs["sponsor"]; !ok && isSponsor {
+				rs["sponsor"] = ref
+			}
+		}
+	}
+	set(rs, "associatedParty", parties)
+}
+
+func (b *builder) arm(arm *models.Arm) Resource {
+	name := text(arm.Name, arm.Label)
+	if name == "" {
+		name = arm.ID
+	}
+	r := Resource{"name": name}
+	set(r, "type", concept(arm.Type))
+	set(r, "description", text(arm.Description))
+	if b.release == R5 {
+		r["linkId"] = arm.ID
+	}
+	return r
+}
+
+func (b *builder) objective(objective *models.Objective) Resource {
+	r := Resource{}
+	set(r, "name", text(objective.Name, objective.Label))
+	set(r, "type", concept(objective.Level))
+	if b.release == R5 {
+		set(r, "description", text(objective.Text, objective.Description))
+	}
+	return r
+}
+
+// indicationConcept is the condition an indication names, coded if it has
+// codes.
+func indicationConcept(indication *models.Indication) *CodeableConcept {
+	cc := &CodeableConcept{Text: text(indication.Description, indication.Name, indication.Label)}
+	for _, c := range indication.Codes {
+		if c != nil {
+			cc.Coding = append(cc.Coding, concept(c).Coding...)
+		}
+	}
+	return cc
+}
+
+func (b *builder) organization(org *models.Organization) {
+	r := Resource{
+		"resourceType": "Organization",
+		"id":           resourceID(org.ID),
+	}
+	set(r, "name", text(org.Name, org.Label))
+	if org.Identifier != nil && *org.Identifier != "" {
+		identifier := Resource{"value": *org.Identifier}
+		if org.IdentifierScheme != nil && *org.IdentifierScheme != "" {
+			identifier["type"] = &CodeableConcept{Text: *org.IdentifierScheme}
+		}
+		r["identifier"] = []Resource{identifier}
+	}
+	if org.Type != nil {
+		r["type"] = []*CodeableConcept{concept(&models.Code{
+			Code:              org.Type.Code,
+			CodeSystem:        org.Type.CodeSystem,
+			CodeSystemVersion: org.Type.CodeSystemVersion,
+			Decode:            org.Type.Decode,
+		})}
+	}
+	if addr := address(org.LegalAddress); addr != nil {
+		// R5 moved an organization's address into its contacts.
+		if b.release == R5 {
+			r["contact"] = []Resource{{"address": addr}}
+		} else {
+			r["address"] = []Resource{addr}
+		}
+	}
+	b.add(r)
+}
+
+func address(a *models.LegalAddress) Resource {
+	if a == nil {
+		return nil
+	}
+	r := Resource{}
+	set(r, "text", a.Text)
+	set(r, "line", a.Lines)
+	set(r, "city", a.City)
+	set(r, "district", a.District)
+	set(r, "state", a.State)
+	set(r, "postalCode", a.PostalCode)
+	if a.Country != nil {
+		set(r, "country", a.Country.Decode)
+	}
+	return r
+}
@@ -0,0 +1,196 @@
+// Package graphread reads whole study documents back out of the graph, for
+// the exports and comparisons that need every field rather than a GraphQL
+// selection.
+package graphread
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+)
+
+// ErrNotFound is returned when no study or study version matches.
+var ErrNotFound = errors.New("not found")
+
+// Study reads the study written for scope, a study id or the snapshot key
+// of one of its revisions, as a document shaped like the one submitted.
+func Study(g *gremlingo.GraphTraversalSource, scope string) (map[string]any, error) {
+	nodes, edges, err := Subgraph(g, scope)
+	if err != nil {
+		return nil, err
+	}
+	doc := graphschema.USDM.Document(scope, nodes, edges)
+	if doc == nil {
+		return nil, ErrNotFound
+	}
+	return doc, nil
+}
+
+// Subgraph reads the nodes written for scope, by key, and the edges
+// leaving them, which Study rebuilds the document from.
+func Subgraph(g *gremlingo.GraphTraversalSource, scope string) (map[string]graphschema.StoredNode, []graphschema.StoredEdge, error) {
+	nodeResults, err := g.V().Has(graphschema.StudyProperty, scope).
+		Project("key", "label", "properties").
+		By(graphschema.KeyProperty).
+		By(gremlingo.T.Label).
+		By(gremlingo.T__.ValueMap().By(gremlingo.T__.Unfold())).
+		ToList()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read nodes of study %s: %w", scope, err)
+	}
+	if len(nodeResults) == 0 {
+		return nil, nil, ErrNotFound
+	}
+
+	nodes := make(map[string]graphschema.StoredNode, len(nodeResults))
+	for _, result := range nodeResults {
+		m, ok := stringMap(result.Data)
+		if !ok {
+			return nil, nil, fmt.Errorf("unexpected node result %v", result.Data)
+		}
+		key, _ := m["key"].(string)
+		label, _ := m["label"].(string)
+		properties, _ := stringMap(m["properties"])
+		nodes[key] = graphschema.StoredNode{Label: label, Properties: properties}
+	}
+
+	edgeResults, err := g.V().Has(graphschema.StudyProperty, scope).OutE().
+		Project("from", "label", "to").
+		By(gremlingo.T__.OutV().Values(graphschema.KeyProperty)).
+		By(gremlingo.T.Label).
+		By(gremlingo.T__.InV().Values(graphschema.KeyProperty)).
+		ToList()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read edges of study %s: %w", scope, err)
+	}
+
+	edges := make([]graphschema.StoredEdge, 0, len(edgeResults))
+	for _, result := range edgeResults {
+		m, ok := stringMap(result.Data)
+		if !ok {
+			return nil, nil, fmt.Errorf("unexpected edge result %v", result.Data)
+		}
+		from, _ := m["from"].(string)
+		label, _ := m["label"].(string)
+		to, _ := m["to"].(string)
+		edges = append(edges, graphschema.StoredEdge{From: from, Label: label, To: to})
+	}
+	return nodes, edges, nil
+}
+
+// StudyModel reads the live study studyID, or its revision if that is
+// set, into the canonical model. Writing what it returns writes the same
+// nodes and edges again. With versionID only that version of the study is
+// kept.
+func StudyModel(g *gremlingo.GraphTraversalSource, studyID string, revision int, versionID string) (models.Study, error) {
+	scope := studyID
+	if revision > 0 {
+		scope = graphschema.SnapshotKey(studyID, revision)
+	}
+	doc, err := Study(g, scope)
+	if err != nil {
+		return models.Study{}, err
+	}
+
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return models.Study{}, fmt.Errorf("failed to marshal study %s: %w", scope, err)
+	}
+	var study models.Study
+	if err := json.Unmarshal(encoded, &study); err != nil {
+		return models.Study{}, fmt.Errorf("failed to decode study %s: %w", scope, err)
+	}
+
+	if versionID == "" {
+		return study, nil
+	}
+	for _, v := range study.Versions {
+		if v != nil && v.ID == versionID {
+			study.Versions = []*models.StudyVersion{v}
+			return study, nil
+		}
+	}
+	return models.Study{}, ErrNotFound
+}
+
+// VersionRef names a study version: its document id and, optionally, the
+// study it belongs to and a revision of that study. Without a study the id
+// must belong to a single live study; a revision requires the study.
+type VersionRef struct {
+	ID       string
+	StudyID  string
+	Revision int
+}
+
+func (r VersionRef) String() string {
+	s := r.ID
+	if r.StudyID != "" {
+		s = r.StudyID + "/" + s
+	}
+	if r.Revision > 0 {
+		s = fmt.Sprintf("%s@%d", s, r.Revision)
+	}
+	return s
+}
+
+// StudyVersion reads the study version ref names, as a document.
+func StudyVersion(g *gremlingo.GraphTraversalSource, ref VersionRef) (map[string]any, error) {
+	studyID := ref.StudyID
+	if studyID == "" {
+		if ref.Revision > 0 {
+			return nil, fmt.Errorf("study version %s: a revision requires the study id", ref.ID)
+		}
+		owners, err := g.V().HasLabel(graphschema.StudyVersion).Has("id", ref.ID).
+			HasNot(graphschema.SnapshotOfProperty).
+			Values(graphschema.StudyProperty).Limit(2).ToList()
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up study version %s: %w", ref.ID, err)
+		}
+		switch len(owners) {
+		case 0:
+			return nil, ErrNotFound
+		case 1:
+			studyID = owners[0].GetString()
+		default:
+			return nil, fmt.Errorf("study version %s exists in more than one study, the study id is required", ref.ID)
+		}
+	}
+
+	scope := studyID
+	if ref.Revision > 0 {
+		scope = graphschema.SnapshotKey(studyID, ref.Revision)
+	}
+
+	study, err := Study(g, scope)
+	if err != nil {
+		return nil, err
+	}
+	versions, _ := study["versions"].([]any)
+	for _, v := range versions {
+		if version, ok := v.(map[string]any); ok && version["id"] == ref.ID {
+			return version, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// stringMap converts a map decoded by the Gremlin driver, whose keys are
+// untyped, into a map keyed by string.
+func stringMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case map[any]any:
+		out := make(map[string]any, len(m))
+		for k, val := range m {
+			out[fmt.Sprint(k)] = val
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,153 @@
+package graphschema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UpsertQueries generates the Cypher that writes a document rooted at the
+// schema root, passed in as the map parameter $param. There is one
+// statement per edge path, ordered parents first, followed by one statement
+// per reference path. All statements are idempotent MERGEs keyed on the
+// study-qualified key (see Key) and are meant to run in a single
+// transaction.
+//
+// The nodes are written for the study id passed in as $scope, which is the
+// document's own id for the live study and a snapshot key (see
+// SnapshotKey) for a revision of it.
+func (s *Schema) UpsertQueries(param, scope string) []string {
+	root, _ := s.Node(s.Root)
+	scopeExpr := "$" + scope
+	queries := []string{fmt.Sprintf(
+		"MERGE (n:%s {%s: %s})%s",
+		root.Label, KeyProperty, s.keyExpr(root.Label, scopeExpr, scopeExpr),
+		setClause("n", "$"+param, scopeExpr, root.Properties),
+	)}
+
+	var refQueries []string
+	var walk func(path []*Edge)
+	walk = func(path []*Edge) {
+		last := path[len(path)-1]
+		queries = append(queries, s.upsertEdgeQuery(param, scopeExpr, path))
+		refQueries = append(refQueries, s.referenceQueries(param, scopeExpr, path, last.To)...)
+
+		for _, child := range s.Children(last.To) {
+			walk(append(path[:len(path):len(path)], child))
+		}
+	}
+
+	refQueries = append(refQueries, s.referenceQueries(param, scopeExpr, nil, s.Root)...)
+	for _, e := range s.Children(s.Root) {
+		walk([]*Edge{e})
+	}
+
+	return append(queries, refQueries...)
+}
+
+// unwindPath emits one UNWIND per edge of path starting at $param and
+// returns the statement so far together with the variable bound to the last
+// document in the path. To-one fields are wrapped in a list so they unwind
+// to zero or one rows.
+func unwindPath(param string, path []*Edge) (*strings.Builder, string) {
+	var b strings.Builder
+	parentVar := "$" + param
+	for i, e := range path {
+		v := fmt.Sprintf("x%d", i)
+		source := parentVar + "." + e.Field
+		if e.Cardinality == ToOne {
+			source = fmt.Sprintf("CASE WHEN %[1]s IS NULL THEN [] ELSE [%[1]s] END", source)
+		}
+		fmt.Fprintf(&b, "UNWIND %s AS %s\n", source, v)
+		parentVar = v
+	}
+	return &b, parentVar
+}
+
+func (s *Schema) upsertEdgeQuery(param, studyExpr string, path []*Edge) string {
+	last := path[len(path)-1]
+	b, docVar := unwindPath(param, path)
+
+	parentVar := "$" + param
+	if len(path) > 1 {
+		parentVar = fmt.Sprintf("x%d", len(path)-2)
+	}
+
+	child, _ := s.Node(last.To)
+	fmt.Fprintf(b, "MATCH (p:%s {%s: %s}) WHERE %s.id IS NOT NULL\n",
+		last.From, KeyProperty, s.keyExpr(last.From, studyExpr, parentVar+".id"), docVar)
+	fmt.Fprintf(b, "MERGE (n:%s {%s: %s})%s\n",
+		child.Label, KeyProperty, s.keyExpr(child.Label, studyExpr, docVar+".id"),
+		setClause("n", docVar, studyExpr, child.Properties))
+	fmt.Fprintf(b, "MERGE (p)-[:%s]->(n)", last.Label)
+	return b.String()
+}
+
+func (s *Schema) referenceQueries(param, studyExpr string, path []*Edge, label string) []string {
+	var queries []string
+	for _, r := range s.References {
+		if r.From != label {
+			continue
+		}
+		b, docVar := unwindPath(param, path)
+		fmt.Fprintf(b, "MATCH (n:%s {%s: %s})\n", r.From, KeyProperty, s.keyExpr(r.From, studyExpr, docVar+".id"))
+		refID := docVar + "." + r.Property
+		if r.Cardinality == ToMany {
+			fmt.Fprintf(b, "UNWIND %s AS rid\n", refID)
+			refID = "rid"
+		}
+		fmt.Fprintf(b, "MATCH (r:%s {%s: %s})\n", r.To, KeyProperty, s.keyExpr(r.To, studyExpr, refID))
+		if r.Inverse {
+			fmt.Fprintf(b, "MERGE (r)-[:%s]->(n)", r.Label)
+		} else {
+			fmt.Fprintf(b, "MERGE (n)-[:%s]->(r)", r.Label)
+		}
+		queries = append(queries, b.String())
+	}
+	return queries
+}
+
+// setClause copies the document id, the owning study's id and properties
+// onto the node.
+func setClause(nodeVar, docVar, studyExpr string, properties []string) string {
+	assignments := []string{
+		fmt.Sprintf("%s.id = %s.id", nodeVar, docVar),
+		fmt.Sprintf("%s.%s = %s", nodeVar, StudyProperty, studyExpr),
+	}
+	for _, p := range properties {
+		assignments = append(assignments, fmt.Sprintf("%s.%s = %s.%s", nodeVar, p, docVar, p))
+	}
+	return "\nSET " + strings.Join(assignments, ", ")
+}
+
+// StudyNodesQuery returns the key, labels and properties of every node
+// written for the study passed in as $studyId.
+func StudyNodesQuery() string {
+	return fmt.Sprintf(
+		"MATCH (n) WHERE n.%s = $studyId\nRETURN n.%s AS key, labels(n) AS labels, properties(n) AS properties",
+		StudyProperty, KeyProperty,
+	)
+}
+
+// PruneQueries generate the Cypher that clears the study passed in as
+// $studyId for a replacing write, in the same transaction as the
+// UpsertQueries that follow. The edges between the study's nodes are
+// dropped, to be merged again by the upsert, so a child or reference the
+// new document no longer has goes with them. The nodes keyed in $removed
+// are then deleted, unless a node of another study still has an edge to
+// them, in which case they are only detached from this one.
+func PruneQueries() []string {
+	return []string{
+		fmt.Sprintf(
+			"MATCH (n)-[r]->(m) WHERE n.%[1]s = $studyId AND m.%[1]s = $studyId\nDELETE r",
+			StudyProperty,
+		),
+		fmt.Sprintf(
+			"MATCH (n) WHERE n.%s = $studyId AND n.%s IN $removed\n"+
+				"OPTIONAL MATCH (o)-->(n)\n"+
+				"WITH n, count(o) AS referrers\n"+
+				"WHERE referrers = 0\n"+
+				"DETACH DELETE n",
+			StudyProperty, KeyProperty,
+		),
+	}
+}
@@ -0,0 +1,187 @@
+package graphschema
+
+import "sort"
+
+// Entity is one node of a document as UpsertQueries writes it.
+type Entity struct {
+	Label      string
+	Key        string
+	ID         string
+	Properties map[string]any
+}
+
+// Entities lists the nodes a document rooted at the schema root writes,
+// by key. A node reached along more than one edge, such as an activity
+// listed by two study designs, is listed once; nodes without an id are
+// skipped, as they are by the upsert.
+func (s *Schema) Entities(doc map[string]any) map[string]Entity {
+	studyID, _ := doc["id"].(string)
+	entities := make(map[string]Entity)
+
+	var walk func(label string, doc map[string]any)
+	walk = func(label string, doc map[string]any) {
+		id, ok := doc["id"].(string)
+		if !ok || id == "" {
+			return
+		}
+
+		key := s.Key(label, studyID, id)
+		if _, seen := entities[key]; !seen {
+			node, _ := s.Node(label)
+			props := make(map[string]any, len(node.Properties))
+			for _, p := range node.Properties {
+				props[p] = doc[p]
+			}
+			entities[key] = Entity{Label: label, Key: key, ID: id, Properties: props}
+		}
+
+		for _, e := range s.Children(label) {
+			switch child := doc[e.Field].(type) {
+			case map[string]any:
+				walk(e.To, child)
+			case []any:
+				for _, item := range child {
+					if m, ok := item.(map[string]any); ok {
+						walk(e.To, m)
+					}
+				}
+			}
+		}
+	}
+	walk(s.Root, doc)
+
+	return entities
+}
+
+// StoredNode is a node as read back from the graph.
+type StoredNode struct {
+	Label      string
+	Properties map[string]any
+}
+
+// StoredEdge is an edge as read back from the graph, between node keys.
+type StoredEdge struct {
+	From  string
+	Label string
+	To    string
+}
+
+// bookkeeping are the properties the writer adds to every node rather than
+// copying them from the document.
+var bookkeeping = map[string]bool{
+	KeyProperty:         true,
+	StudyProperty:       true,
+	RevisionProperty:    true,
+	IngestedAtProperty:  true,
+	SnapshotOfProperty:  true,
+	SubmittedAtProperty: true,
+}
+
+// Bookkeeping reports whether property is one the writer adds to every
+// node rather than copying it from the document.
+func Bookkeeping(property string) bool {
+	return bookkeeping[property]
+}
+
+// Document rebuilds the document rooted at the node keyed rootKey from the
+// nodes and edges written for it, the inverse of UpsertQueries: children
+// are nested under their edge's field and references are turned back into
+// id-valued properties. The graph does not keep the order of a list, so
+// children and referenced ids are ordered by id.
+func (s *Schema) Document(rootKey string, nodes map[string]StoredNode, edges []StoredEdge) map[string]any {
+	out := make(map[string]map[string][]string)
+	in := make(map[string]map[string][]string)
+	for _, e := range edges {
+		if out[e.From] == nil {
+			out[e.From] = make(map[string][]string)
+		}
+		out[e.From][e.Label] = append(out[e.From][e.Label], e.To)
+		if in[e.To] == nil {
+			in[e.To] = make(map[string][]string)
+		}
+		in[e.To][e.Label] = append(in[e.To][e.Label], e.From)
+	}
+
+	// linked returns the sorted ids of the nodes of label among keys.
+	linked := func(keys []string, label string) []string {
+		var ids []string
+		for _, k := range keys {
+			if n, ok := nodes[k]; ok && n.Label == label {
+				if id, ok := n.Properties["id"].(string); ok {
+					ids = append(ids, id)
+				}
+			}
+		}
+		sort.Strings(ids)
+		return ids
+	}
+	// byID returns the keys of the nodes of label among keys, ordered by
+	// their ids.
+	byID := func(keys []string, label string) []string {
+		ordered := append([]string(nil), keys...)
+		sort.Slice(ordered, func(i, j int) bool {
+			a, _ := nodes[ordered[i]].Properties["id"].(string)
+			b, _ := nodes[ordered[j]].Properties["id"].(string)
+			return a < b
+		})
+		var matching []string
+		for _, k := range ordered {
+			if nodes[k].Label == label {
+				matching = append(matching, k)
+			}
+		}
+		return matching
+	}
+
+	var build func(key string) map[string]any
+	build = func(key string) map[string]any {
+		node := nodes[key]
+		doc := make(map[string]any, len(node.Properties))
+		for p, v := range node.Properties {
+			if !bookkeeping[p] {
+				doc[p] = v
+			}
+		}
+
+		for _, e := range s.Children(node.Label) {
+			children := byID(out[key][e.Label], e.To)
+			if e.Cardinality == ToOne {
+				if len(children) > 0 {
+					doc[e.Field] = build(children[0])
+				}
+				continue
+			}
+			list := make([]any, 0, len(children))
+			for _, child := range children {
+				list = append(list, build(child))
+			}
+			if len(list) > 0 {
+				doc[e.Field] = list
+			}
+		}
+
+		for _, r := range s.References {
+			if r.From != node.Label {
+				continue
+			}
+			keys := out[key][r.Label]
+			if r.Inverse {
+				keys = in[key][r.Label]
+			}
+			ids := linked(keys, r.To)
+			switch {
+			case len(ids) == 0:
+			case r.Cardinality == ToMany:
+				doc[r.Property] = ids
+			default:
+				doc[r.Property] = ids[0]
+			}
+		}
+		return doc
+	}
+
+	if _, ok := nodes[rootKey]; !ok {
+		return nil
+	}
+	return build(rootKey)
+}
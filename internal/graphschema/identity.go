@@ -0,0 +1,32 @@
+package graphschema
+
+// USDM ids are only unique within the document that declares them: every
+// generator emits Activity_1, Epoch_1 and so on. Nodes are therefore keyed
+// by KeyProperty, the id qualified with the id of the study the node was
+// written for, and carry that study's id in StudyProperty. The document id
+// stays in "id", which is what the GraphQL API returns.
+const (
+	KeyProperty   = "key"
+	StudyProperty = "studyId"
+
+	keySeparator = "/"
+)
+
+// Key returns the key of the node with the given document id written for
+// studyID. The root node is keyed by studyID itself, which is its own id
+// unless it is a revision; see SnapshotKey.
+func (s *Schema) Key(label, studyID, id string) string {
+	if label == s.Root {
+		return studyID
+	}
+	return studyID + keySeparator + id
+}
+
+// keyExpr is the Cypher counterpart of Key for a document id held in idExpr
+// and the study id held in studyExpr.
+func (s *Schema) keyExpr(label, studyExpr, idExpr string) string {
+	if label == s.Root {
+		return studyExpr
+	}
+	return studyExpr + " + '" + keySeparator + "' + " + idExpr
+}
@@ -0,0 +1,174 @@
+package graphschema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KeyMigrationQueries generate the Cypher that moves a study written before
+// nodes were keyed by study (see Key) onto keyed nodes. The study id is
+// passed in as $studyId and the statements are meant to run in a single
+// transaction per study. The root node is keyed in place; every other node
+// reached from it is copied onto a keyed node, edges first and references
+// after, leaving the legacy nodes to LegacyCleanupQueries.
+//
+// A legacy node that two studies shared is copied into both. Whatever the
+// later write overwrote on it cannot be recovered from the graph; the
+// studies have to be submitted again for that.
+func (s *Schema) KeyMigrationQueries() []string {
+	queries := []string{fmt.Sprintf(
+		"MATCH (n:%s {id: $studyId}) WHERE n.%s IS NULL\nSET n.%s = n.id, n.%s = n.id",
+		s.Root, SnapshotOfProperty, KeyProperty, StudyProperty,
+	)}
+
+	var walk func(path []*Edge)
+	walk = func(path []*Edge) {
+		queries = append(queries, s.migrateEdgeQuery(path))
+		last := path[len(path)-1]
+		for _, child := range s.Children(last.To) {
+			walk(append(path[:len(path):len(path)], child))
+		}
+	}
+	for _, e := range s.Children(s.Root) {
+		walk([]*Edge{e})
+	}
+
+	for _, r := range s.References {
+		if r.From == s.Root {
+			queries = append(queries, s.migrateReferenceQuery(r, nil))
+			continue
+		}
+		for _, path := range s.pathsTo(r.From) {
+			queries = append(queries, s.migrateReferenceQuery(r, path))
+		}
+	}
+	return queries
+}
+
+// pathsTo returns every chain of edges from the root down to label.
+func (s *Schema) pathsTo(label string) [][]*Edge {
+	var paths [][]*Edge
+	var walk func(path []*Edge)
+	walk = func(path []*Edge) {
+		last := path[len(path)-1]
+		if last.To == label {
+			paths = append(paths, path)
+		}
+		for _, child := range s.Children(last.To) {
+			walk(append(path[:len(path):len(path)], child))
+		}
+	}
+	for _, e := range s.Children(s.Root) {
+		walk([]*Edge{e})
+	}
+	return paths
+}
+
+// matchLegacyPath writes a MATCH of the legacy nodes along path from the
+// study's root, naming them o0, o1 and so on.
+func (s *Schema) matchLegacyPath(b *strings.Builder, path []*Edge) {
+	fmt.Fprintf(b, "MATCH (r:%s {id: $studyId})", s.Root)
+	conditions := []string{fmt.Sprintf("r.%s IS NULL", SnapshotOfProperty)}
+	for i, e := range path {
+		fmt.Fprintf(b, "-[:%s]->(o%d:%s)", e.Label, i, e.To)
+		conditions = append(conditions, fmt.Sprintf("o%d.%s IS NULL", i, KeyProperty))
+	}
+	fmt.Fprintf(b, "\nWHERE %s\n", strings.Join(conditions, " AND "))
+}
+
+// LegacyCleanupQueries generate the Cypher that deletes the legacy nodes
+// of the study passed in as $studyId once KeyMigrationQueries have copied
+// them, meant to run in the same transaction. Like the migration they only
+// follow edges from the study's legacy root, deepest first so that every
+// node is still reachable when its turn comes, and they keep a node that
+// a study not migrated yet still reaches, so that it can be copied there
+// too. Nothing outside the study is touched.
+func (s *Schema) LegacyCleanupQueries() []string {
+	var queries []string
+	var walk func(path []*Edge)
+	walk = func(path []*Edge) {
+		last := path[len(path)-1]
+		for _, child := range s.Children(last.To) {
+			walk(append(path[:len(path):len(path)], child))
+		}
+		queries = append(queries, s.deleteLegacyQuery(path))
+	}
+	for _, e := range s.Children(s.Root) {
+		walk([]*Edge{e})
+	}
+	return queries
+}
+
+// deleteLegacyQuery deletes the legacy node that path leads to from the
+// study's root unless another legacy root reaches it. The study's own root
+// is keyed by then, so it is not one of them.
+func (s *Schema) deleteLegacyQuery(path []*Edge) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "MATCH (r:%s {%s: $studyId})", s.Root, KeyProperty)
+	conditions := []string{fmt.Sprintf("r.%s IS NULL", SnapshotOfProperty)}
+	for i, e := range path {
+		fmt.Fprintf(&b, "-[:%s]->(o%d:%s)", e.Label, i, e.To)
+		conditions = append(conditions, fmt.Sprintf("o%d.%s IS NULL", i, KeyProperty))
+	}
+	last := fmt.Sprintf("o%d", len(path)-1)
+	conditions = append(conditions, fmt.Sprintf(
+		"NOT EXISTS { MATCH (other:%s)-[*]->(%s) WHERE other.%s IS NULL }", s.Root, last, KeyProperty))
+	fmt.Fprintf(&b, "\nWHERE %s\n", strings.Join(conditions, " AND "))
+	fmt.Fprintf(&b, "DETACH DELETE %s", last)
+	return b.String()
+}
+
+// migrateEdgeQuery follows path through legacy nodes from the root and
+// links the keyed copy of the last node to the keyed copy of its parent.
+func (s *Schema) migrateEdgeQuery(path []*Edge) string {
+	var b strings.Builder
+	s.matchLegacyPath(&b, path)
+
+	last := path[len(path)-1]
+	parentID := "r.id"
+	if len(path) > 1 {
+		parentID = fmt.Sprintf("o%d.id", len(path)-2)
+	}
+	oldVar := fmt.Sprintf("o%d", len(path)-1)
+
+	fmt.Fprintf(&b, "MATCH (p:%s {%s: %s})\n", last.From, KeyProperty, s.keyExpr(last.From, "$studyId", parentID))
+	fmt.Fprintf(&b, "MERGE (n:%s {%s: %s})\n", last.To, KeyProperty, s.keyExpr(last.To, "$studyId", oldVar+".id"))
+	fmt.Fprintf(&b, "ON CREATE SET n += properties(%s)\n", oldVar)
+	fmt.Fprintf(&b, "SET n.%s = %s, n.%s = $studyId\n", KeyProperty, s.keyExpr(last.To, "$studyId", oldVar+".id"), StudyProperty)
+	fmt.Fprintf(&b, "MERGE (p)-[:%s]->(n)", last.Label)
+	return b.String()
+}
+
+// migrateReferenceQuery copies the reference edges of the r.From nodes
+// that path leads to from the study's legacy root onto their keyed copies,
+// dropping those that point outside the study. Matching legacy nodes by
+// path rather than by id keeps another study's node of the same id out;
+// a nil path stands for the root, which was keyed in place.
+func (s *Schema) migrateReferenceQuery(r Reference, path []*Edge) string {
+	var b strings.Builder
+	legacy := "n"
+	if path == nil {
+		fmt.Fprintf(&b, "MATCH (n:%s {%s: $studyId})\n", r.From, KeyProperty)
+	} else {
+		legacy = fmt.Sprintf("o%d", len(path)-1)
+		s.matchLegacyPath(&b, path)
+		fmt.Fprintf(&b, "MATCH (n:%s {%s: %s})\n", r.From, KeyProperty, s.keyExpr(r.From, "$studyId", legacy+".id"))
+	}
+
+	if r.Inverse {
+		fmt.Fprintf(&b, "MATCH (t:%s)-[:%s]->(%s)", r.To, r.Label, legacy)
+	} else {
+		fmt.Fprintf(&b, "MATCH (%s)-[:%s]->(t:%s)", legacy, r.Label, r.To)
+	}
+	if r.To != s.Root {
+		fmt.Fprintf(&b, " WHERE t.%s IS NULL", KeyProperty)
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "MATCH (m:%s {%s: %s})\n", r.To, KeyProperty, s.keyExpr(r.To, "$studyId", "t.id"))
+	if r.Inverse {
+		fmt.Fprintf(&b, "MERGE (m)-[:%s]->(n)", r.Label)
+	} else {
+		fmt.Fprintf(&b, "MERGE (n)-[:%s]->(m)", r.Label)
+	}
+	return b.String()
+}
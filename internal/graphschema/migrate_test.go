@@ -0,0 +1,63 @@
+package graphschema
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestMigrationStaysInStudy checks that every migration statement reaches
+// legacy nodes from the migrated study's root, so that a node another
+// study holds under the same id is never copied into it.
+func TestMigrationStaysInStudy(t *testing.T) {
+	root := "MATCH (r:" + USDM.Root + " {id: $studyId})"
+	for _, q := range USDM.KeyMigrationQueries()[1:] {
+		if strings.Contains(q, "{id: n.id}") {
+			t.Errorf("statement matches legacy nodes by id alone:\n%s", q)
+		}
+		if !strings.HasPrefix(q, root) && !strings.HasPrefix(q, "MATCH (n:"+USDM.Root+" {"+KeyProperty+": $studyId})") {
+			t.Errorf("statement does not start from the study's root:\n%s", q)
+		}
+	}
+}
+
+func TestMigrationCopiesEveryReference(t *testing.T) {
+	queries := strings.Join(USDM.KeyMigrationQueries(), "\n")
+	for _, r := range USDM.References {
+		merge := "MERGE (n)-[:" + r.Label + "]->(m)"
+		if r.Inverse {
+			merge = "MERGE (m)-[:" + r.Label + "]->(n)"
+		}
+		if !strings.Contains(queries, merge) {
+			t.Errorf("no statement copies %s.%s (%s)", r.From, r.Property, r.Label)
+		}
+		if r.From != USDM.Root && len(USDM.pathsTo(r.From)) == 0 {
+			t.Errorf("%s is not reachable from %s", r.From, USDM.Root)
+		}
+	}
+}
+
+// TestLegacyCleanupStaysInStudy checks that the cleanup only deletes
+// legacy nodes reached from the migrated study's root, and that a node is
+// deleted only after every node below it.
+func TestLegacyCleanupStaysInStudy(t *testing.T) {
+	root := "MATCH (r:" + USDM.Root + " {" + KeyProperty + ": $studyId})-["
+	queries := USDM.LegacyCleanupQueries()
+	if len(queries) == 0 {
+		t.Fatal("no cleanup statements")
+	}
+
+	paths := make([]string, len(queries))
+	for i, q := range queries {
+		if !strings.HasPrefix(q, root) {
+			t.Errorf("statement does not start from the study's root:\n%s", q)
+		}
+		paths[i], _, _ = strings.Cut(q, "\n")
+	}
+	for i, path := range paths {
+		for _, later := range paths[i+1:] {
+			if strings.HasPrefix(later, path) {
+				t.Errorf("%s is deleted before the nodes below it", path)
+			}
+		}
+	}
+}
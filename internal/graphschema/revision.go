@@ -0,0 +1,75 @@
+package graphschema
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Every ingestion of a study also writes an immutable snapshot of it: a
+// copy of the whole document written for the study id SnapshotKey(id, n)
+// rather than id, so the copy shares no node with the live study or with
+// another revision. Its nodes carry the revision number, the ingestion
+// time and, in SnapshotOfProperty, the id of the study they are a snapshot
+// of; live nodes have no SnapshotOfProperty. A snapshot's root SUPERSEDES
+// the root of the revision before it.
+const (
+	RevisionProperty   = "revision"
+	IngestedAtProperty = "ingestedAt"
+	SnapshotOfProperty = "snapshotOf"
+
+	// SubmittedAtProperty is when the submission that last wrote a live
+	// study was accepted, formatted like IngestedAtProperty. An older
+	// submission of the study is not written over it.
+	SubmittedAtProperty = "submittedAt"
+
+	Supersedes = "SUPERSEDES"
+
+	revisionSeparator = "@"
+)
+
+// IngestedAtLayout is how ingestion times are stored: UTC with a fixed
+// number of digits, so that they compare as strings.
+const IngestedAtLayout = "2006-01-02T15:04:05.000Z"
+
+// FormatIngestedAt formats t for IngestedAtProperty.
+func FormatIngestedAt(t time.Time) string {
+	return t.UTC().Format(IngestedAtLayout)
+}
+
+// SnapshotKey is the study id revision n of the study is written for, and
+// so the key of the snapshot's root.
+func SnapshotKey(studyID string, revision int) string {
+	return studyID + revisionSeparator + strconv.Itoa(revision)
+}
+
+// LatestRevisionQuery returns the highest revision of the study passed in
+// as $studyId, or null if it has none.
+func (s *Schema) LatestRevisionQuery() string {
+	return fmt.Sprintf(
+		"MATCH (n:%s) WHERE n.%s = $studyId\nRETURN max(n.%s) AS revision",
+		s.Root, SnapshotOfProperty, RevisionProperty,
+	)
+}
+
+// SnapshotQueries generate the Cypher that writes revision $revision of the
+// document passed in as $param, for the study id $snapshotId, stamped with
+// $ingestedAt. The live root is stamped with the same revision, so that
+// the study reports the revision it is at, and with $submittedAt unless
+// that is null.
+func (s *Schema) SnapshotQueries(param string) []string {
+	return append(s.UpsertQueries(param, "snapshotId"),
+		fmt.Sprintf(
+			"MATCH (n) WHERE n.%s = $snapshotId\nSET n.%s = $revision, n.%s = $ingestedAt, n.%s = $%s.id",
+			StudyProperty, RevisionProperty, IngestedAtProperty, SnapshotOfProperty, param,
+		),
+		fmt.Sprintf(
+			"MATCH (n:%[1]s {%[2]s: $snapshotId})\nMATCH (p:%[1]s) WHERE p.%[3]s = $%[4]s.id AND p.%[5]s = $revision - 1\nMERGE (n)-[:%[6]s]->(p)",
+			s.Root, KeyProperty, SnapshotOfProperty, param, RevisionProperty, Supersedes,
+		),
+		fmt.Sprintf(
+			"MATCH (n:%[1]s {%[2]s: $%[3]s.id})\nSET n.%[4]s = $revision, n.%[5]s = $ingestedAt, n.%[6]s = coalesce($submittedAt, n.%[6]s)",
+			s.Root, KeyProperty, param, RevisionProperty, IngestedAtProperty, SubmittedAtProperty,
+		),
+	)
+}
@@ -0,0 +1,242 @@
+// Package graphschema describes the shape of the SDR graph in Neptune: every
+// node label, the edges that connect them and how many children an edge
+// carries. The processor generates its upsert Cypher from it and the
+// resolver generates its read traversals from it, so the writer and the
+// readers cannot disagree on a label.
+package graphschema
+
+import (
+	"fmt"
+)
+
+type Cardinality int
+
+const (
+	ToOne Cardinality = iota
+	ToMany
+)
+
+// Node is a vertex label and the scalar properties copied onto it from the
+// document. Every node is keyed by its study-qualified KeyProperty and
+// keeps its document id in "id".
+type Node struct {
+	Label      string
+	Properties []string
+}
+
+// Edge connects a parent node to the children found under Field in the
+// parent's document. Field is also the GraphQL field name on the parent.
+type Edge struct {
+	From        string
+	Field       string
+	Label       string
+	To          string
+	Cardinality Cardinality
+}
+
+// Reference is an edge derived from an id-valued property rather than from
+// nesting, e.g. StudyCell.armId. The edge runs from the node holding the
+// property to the referenced node, unless Inverse is set, as for
+// Epoch.previousId where the earlier epoch PRECEDES the later one. ToMany
+// references hold a list of ids. Field, if set, is the GraphQL field on
+// the holder that resolves to the referenced node itself.
+type Reference struct {
+	From        string
+	Property    string
+	Field       string
+	Label       string
+	To          string
+	Cardinality Cardinality
+	Inverse     bool
+}
+
+// Backlink is a GraphQL field that walks an Edge from the child back to its
+// parent, e.g. StudyVersion.study over HAS_VERSION. Nothing is written for
+// it. A child that several parents share, as activities and encounters can
+// be, has a ToMany backlink.
+type Backlink struct {
+	From        string
+	Field       string
+	Label       string
+	To          string
+	Cardinality Cardinality
+}
+
+type Schema struct {
+	Root       string
+	Nodes      []Node
+	Edges      []Edge
+	References []Reference
+	Backlinks  []Backlink
+
+	nodes     map[string]*Node
+	edges     map[string]map[string]*Edge
+	refFields map[string]map[string]*Reference
+	refProps  map[string]map[string]*Reference
+	backlinks map[string]map[string]*Backlink
+}
+
+// New indexes and validates a schema. It returns an error if an edge,
+// reference or backlink points at an undeclared label, a backlink has no
+// edge to walk, or a field is declared twice.
+func New(root string, nodes []Node, edges []Edge, refs []Reference, backlinks []Backlink) (*Schema, error) {
+	s := &Schema{
+		Root:       root,
+		Nodes:      nodes,
+		Edges:      edges,
+		References: refs,
+		Backlinks:  backlinks,
+		nodes:      make(map[string]*Node, len(nodes)),
+		edges:      make(map[string]map[string]*Edge),
+		refFields:  make(map[string]map[string]*Reference),
+		refProps:   make(map[string]map[string]*Reference),
+		backlinks:  make(map[string]map[string]*Backlink),
+	}
+
+	for i := range s.Nodes {
+		n := &s.Nodes[i]
+		if _, dup := s.nodes[n.Label]; dup {
+			return nil, fmt.Errorf("node %s declared twice", n.Label)
+		}
+		s.nodes[n.Label] = n
+	}
+
+	if _, ok := s.nodes[root]; !ok {
+		return nil, fmt.Errorf("root node %s is not declared", root)
+	}
+
+	for i := range s.Edges {
+		e := &s.Edges[i]
+		if _, ok := s.nodes[e.From]; !ok {
+			return nil, fmt.Errorf("edge %s: unknown parent node %s", e.Label, e.From)
+		}
+		if _, ok := s.nodes[e.To]; !ok {
+			return nil, fmt.Errorf("edge %s: unknown child node %s", e.Label, e.To)
+		}
+		if s.edges[e.From] == nil {
+			s.edges[e.From] = make(map[string]*Edge)
+		}
+		if _, dup := s.edges[e.From][e.Field]; dup {
+			return nil, fmt.Errorf("field %s.%s declared twice", e.From, e.Field)
+		}
+		s.edges[e.From][e.Field] = e
+	}
+
+	for i := range s.References {
+		r := &s.References[i]
+		if _, ok := s.nodes[r.From]; !ok {
+			return nil, fmt.Errorf("reference %s: unknown node %s", r.Label, r.From)
+		}
+		if _, ok := s.nodes[r.To]; !ok {
+			return nil, fmt.Errorf("reference %s: unknown node %s", r.Label, r.To)
+		}
+		if s.refProps[r.From] == nil {
+			s.refProps[r.From] = make(map[string]*Reference)
+			s.refFields[r.From] = make(map[string]*Reference)
+		}
+		s.refProps[r.From][r.Property] = r
+		if r.Field == "" {
+			continue
+		}
+		if _, dup := s.edges[r.From][r.Field]; dup {
+			return nil, fmt.Errorf("field %s.%s declared twice", r.From, r.Field)
+		}
+		if _, dup := s.refFields[r.From][r.Field]; dup {
+			return nil, fmt.Errorf("field %s.%s declared twice", r.From, r.Field)
+		}
+		s.refFields[r.From][r.Field] = r
+	}
+
+	for i := range s.Backlinks {
+		b := &s.Backlinks[i]
+		if _, ok := s.nodes[b.From]; !ok {
+			return nil, fmt.Errorf("backlink %s.%s: unknown node %s", b.From, b.Field, b.From)
+		}
+		if _, ok := s.nodes[b.To]; !ok {
+			return nil, fmt.Errorf("backlink %s.%s: unknown node %s", b.From, b.Field, b.To)
+		}
+		if !s.hasEdge(b.To, b.Label, b.From) {
+			return nil, fmt.Errorf("backlink %s.%s: no %s edge from %s", b.From, b.Field, b.Label, b.To)
+		}
+		if _, dup := s.edges[b.From][b.Field]; dup {
+			return nil, fmt.Errorf("field %s.%s declared twice", b.From, b.Field)
+		}
+		if _, dup := s.refFields[b.From][b.Field]; dup {
+			return nil, fmt.Errorf("field %s.%s declared twice", b.From, b.Field)
+		}
+		if s.backlinks[b.From] == nil {
+			s.backlinks[b.From] = make(map[string]*Backlink)
+		}
+		if _, dup := s.backlinks[b.From][b.Field]; dup {
+			return nil, fmt.Errorf("field %s.%s declared twice", b.From, b.Field)
+		}
+		s.backlinks[b.From][b.Field] = b
+	}
+
+	return s, nil
+}
+
+// Node returns the node declared for label.
+func (s *Schema) Node(label string) (*Node, bool) {
+	n, ok := s.nodes[label]
+	return n, ok
+}
+
+// Edge returns the edge declared for field on the parent label.
+func (s *Schema) Edge(parentLabel, field string) (*Edge, bool) {
+	e, ok := s.edges[parentLabel][field]
+	return e, ok
+}
+
+// ReferenceField returns the reference resolved by field on label, e.g.
+// StudyCell.arm.
+func (s *Schema) ReferenceField(label, field string) (*Reference, bool) {
+	r, ok := s.refFields[label][field]
+	return r, ok
+}
+
+// ReferenceProperty returns the reference held in property on label, e.g.
+// StudyCell.elementIds.
+func (s *Schema) ReferenceProperty(label, property string) (*Reference, bool) {
+	r, ok := s.refProps[label][property]
+	return r, ok
+}
+
+// Backlink returns the backlink declared for field on label.
+func (s *Schema) Backlink(label, field string) (*Backlink, bool) {
+	b, ok := s.backlinks[label][field]
+	return b, ok
+}
+
+func (s *Schema) hasEdge(from, label, to string) bool {
+	for _, e := range s.Edges {
+		if e.From == from && e.Label == label && e.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+// HasProperty reports whether property is copied onto label's nodes.
+func (n *Node) HasProperty(property string) bool {
+	if property == "id" || property == KeyProperty || property == StudyProperty {
+		return true
+	}
+	for _, p := range n.Properties {
+		if p == property {
+			return true
+		}
+	}
+	return false
+}
+
+// Children returns the edges leaving label in declaration order.
+func (s *Schema) Children(label string) []*Edge {
+	var children []*Edge
+	for i := range s.Edges {
+		if s.Edges[i].From == label {
+			children = append(children, &s.Edges[i])
+		}
+	}
+	return children
+}
@@ -0,0 +1,181 @@
+package graphschema
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var (
+	cypherNode = regexp.MustCompile(`\((\w+):(\w+) \{(\w+):`)
+	cypherEdge = regexp.MustCompile(`MERGE \((\w+)\)-\[:(\w+)\]->\((\w+)\)`)
+	cypherSet  = regexp.MustCompile(`(\w+)\.(\w+) = `)
+)
+
+// written is what a set of upsert statements puts in the graph: the
+// properties set on each label and the edges between labels, as
+// "From -LABEL-> To".
+type written struct {
+	properties map[string]map[string]bool
+	edges      map[string]bool
+}
+
+func parseUpserts(t *testing.T, queries []string) written {
+	t.Helper()
+	w := written{properties: make(map[string]map[string]bool), edges: make(map[string]bool)}
+	for _, q := range queries {
+		labels := make(map[string]string)
+		for _, m := range cypherNode.FindAllStringSubmatch(q, -1) {
+			labels[m[1]] = m[2]
+			if w.properties[m[2]] == nil {
+				w.properties[m[2]] = make(map[string]bool)
+			}
+			w.properties[m[2]][m[3]] = true
+		}
+		for _, line := range strings.Split(q, "\n") {
+			if !strings.HasPrefix(line, "SET ") {
+				continue
+			}
+			for _, m := range cypherSet.FindAllStringSubmatch(line, -1) {
+				label, ok := labels[m[1]]
+				if !ok {
+					t.Fatalf("SET on unbound variable %s in\n%s", m[1], q)
+				}
+				w.properties[label][m[2]] = true
+			}
+		}
+		for _, m := range cypherEdge.FindAllStringSubmatch(q, -1) {
+			from, okFrom := labels[m[1]]
+			to, okTo := labels[m[3]]
+			if !okFrom || !okTo {
+				t.Fatalf("MERGE of an edge between unbound variables in\n%s", q)
+			}
+			w.edges[edgeName(from, m[2], to)] = true
+		}
+	}
+	return w
+}
+
+func edgeName(from, label, to string) string {
+	return from + " -" + label + "-> " + to
+}
+
+// read is what the schema declares readable: node properties, Edges and
+// non-inverse References walked out, inverse References and Backlinks
+// walked in. lambdas/resolver/query's tests check that the projector reads
+// exactly this and that every GraphQL field is one of it.
+type read struct {
+	properties map[string][]string
+	edges      map[string]string // edge name -> the field that walks it
+}
+
+func schemaReads(s *Schema) read {
+	r := read{properties: make(map[string][]string), edges: make(map[string]string)}
+	for _, n := range s.Nodes {
+		r.properties[n.Label] = append([]string{"id", KeyProperty, StudyProperty}, n.Properties...)
+	}
+	for _, e := range s.Edges {
+		r.edges[edgeName(e.From, e.Label, e.To)] = e.From + "." + e.Field
+	}
+	for _, ref := range s.References {
+		name := edgeName(ref.From, ref.Label, ref.To)
+		if ref.Inverse {
+			name = edgeName(ref.To, ref.Label, ref.From)
+		}
+		r.edges[name] = ref.From + "." + ref.Property
+	}
+	for _, b := range s.Backlinks {
+		r.edges[edgeName(b.To, b.Label, b.From)] = b.From + "." + b.Field
+	}
+	return r
+}
+
+// drift lists where the upsert statements and the schema disagree.
+func drift(w written, r read) []string {
+	var problems []string
+	for name, field := range r.edges {
+		if !w.edges[name] {
+			problems = append(problems, fmt.Sprintf("%s walks %s, which the upsert never writes", field, name))
+		}
+	}
+	for name := range w.edges {
+		if _, ok := r.edges[name]; !ok {
+			problems = append(problems, fmt.Sprintf("the upsert writes %s, which no field reads", name))
+		}
+	}
+	for label, properties := range r.properties {
+		for _, p := range properties {
+			if !w.properties[label][p] {
+				problems = append(problems, fmt.Sprintf("%s.%s is read but never written", label, p))
+			}
+		}
+	}
+	for label, properties := range w.properties {
+		for p := range properties {
+			if !slices.Contains(r.properties[label], p) {
+				problems = append(problems, fmt.Sprintf("%s.%s is written but never read", label, p))
+			}
+		}
+	}
+	slices.Sort(problems)
+	return problems
+}
+
+func TestUpsertQueriesMatchProjection(t *testing.T) {
+	w := parseUpserts(t, USDM.UpsertQueries("doc", "scope"))
+	for _, problem := range drift(w, schemaReads(USDM)) {
+		t.Error(problem)
+	}
+}
+
+func TestDriftIsDetected(t *testing.T) {
+	queries := USDM.UpsertQueries("doc", "scope")
+	// replace edits the statements, failing if old is not in them.
+	replace := func(t *testing.T, old, new string) []string {
+		t.Helper()
+		edited := slices.Clone(queries)
+		changed := false
+		for i, q := range edited {
+			edited[i] = strings.ReplaceAll(q, old, new)
+			changed = changed || edited[i] != q
+		}
+		if !changed {
+			t.Fatalf("no statement contains %q", old)
+		}
+		return edited
+	}
+
+	t.Run("renamed edge label in the Cypher", func(t *testing.T) {
+		edited := replace(t, "[:"+HasVersion+"]", "[:HAS_STUDY_VERSION]")
+		if problems := drift(parseUpserts(t, edited), schemaReads(USDM)); len(problems) == 0 {
+			t.Error("renaming an edge label on the write side went unnoticed")
+		}
+	})
+
+	t.Run("property dropped from the Cypher", func(t *testing.T) {
+		edited := replace(t, ", n.versionIdentifier = ", ", n.version = ")
+		if problems := drift(parseUpserts(t, edited), schemaReads(USDM)); len(problems) == 0 {
+			t.Error("renaming a property on the write side went unnoticed")
+		}
+	})
+
+	t.Run("backlink label changed on the read side", func(t *testing.T) {
+		b := USDM.Backlinks[0]
+		r := schemaReads(USDM)
+		delete(r.edges, edgeName(b.To, b.Label, b.From))
+		r.edges[edgeName(b.To, "HAS_SOMETHING_ELSE", b.From)] = b.From + "." + b.Field
+		if problems := drift(parseUpserts(t, queries), r); len(problems) == 0 {
+			t.Error("changing a backlink label on the read side went unnoticed")
+		}
+	})
+
+	t.Run("property added on the read side only", func(t *testing.T) {
+		r := schemaReads(USDM)
+		r.properties[StudyVersion] = append(r.properties[StudyVersion], "acronym")
+		if problems := drift(parseUpserts(t, queries), r); len(problems) == 0 {
+			t.Error("reading a property that is never written went unnoticed")
+		}
+	})
+}
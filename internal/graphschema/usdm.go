@@ -0,0 +1,380 @@
+package graphschema
+
+// Node labels.
+const (
+	Study                          = "Study"
+	StudyVersion                   = "StudyVersion"
+	StudyDesign                    = "StudyDesign"
+	StudyDefinitionDocument        = "StudyDefinitionDocument"
+	StudyDefinitionDocumentVersion = "StudyDefinitionDocumentVersion"
+	Arm                            = "Arm"
+	ArmDataOriginType              = "ArmDataOriginType"
+	Epoch                          = "Epoch"
+	StudyElement                   = "StudyElement"
+	StudyCell                      = "StudyCell"
+	TransitionRule                 = "TransitionRule"
+	Encounter                      = "Encounter"
+	EncounterType                  = "EncounterType"
+	Activity                       = "Activity"
+	DefinedProcedure               = "DefinedProcedure"
+	Code                           = "Code"
+	AliasCode                      = "AliasCode"
+	StudyAmendment                 = "StudyAmendment"
+	StudyAmendmentReason           = "StudyAmendmentReason"
+	SubjectEnrollment              = "SubjectEnrollment"
+	GovernanceDate                 = "GovernanceDate"
+	Quantity                       = "Quantity"
+	BioMedicalConcept              = "BioMedicalConcept"
+	BioMedicalConceptCode          = "BioMedicalConceptCode"
+	BCCategory                     = "BCCategory"
+	BCSurrogate                    = "BCSurrogates"
+	Organization                   = "Organization"
+	OrganizationType               = "OrganizationType"
+	LegalAddress                   = "LegalAddress"
+	Country                        = "Country"
+	StudyRole                      = "StudyRole"
+	StudyIntervention              = "StudyIntervention"
+	Administration                 = "Administration"
+	AdministrableProduct           = "AdministrableProduct"
+	Condition                      = "Condition"
+	StudyTitle                     = "StudyTitle"
+	StudyIdentifier                = "StudyIdentifier"
+	ReferenceIdentifier            = "ReferenceIdentifier"
+	Abbreviation                   = "Abbreviation"
+	EligibilityCriterion           = "EligibilityCriterion"
+	EligibilityCriterionItem       = "EligibilityCriterionItem"
+	NarrativeContentItem           = "NarrativeContentItem"
+	Objective                      = "Objective"
+	Endpoint                       = "Endpoint"
+	StudyDesignPopulation          = "StudyDesignPopulation"
+	Indication                     = "Indication"
+	Estimand                       = "Estimand"
+	ScheduleTimeline               = "ScheduleTimeline"
+	ScheduleTimelineExit           = "ScheduleTimelineExit"
+	ScheduledInstance              = "ScheduledInstance"
+	Timing                         = "Timing"
+)
+
+// Edge labels.
+const (
+	HasVersion                 = "HAS_VERSION"
+	DocumentedBy               = "DOCUMENTED_BY"
+	HasDocumentVersion         = "HAS_DOCUMENT_VERSION"
+	IncludesDesign             = "INCLUDES_DESIGN"
+	HasType                    = "HAS_TYPE"
+	HasPhase                   = "HAS_PHASE"
+	HasModel                   = "HAS_MODEL"
+	HasBlindingSchema          = "HAS_BLINDING_SCHEMA"
+	HasTherapeuticArea         = "HAS_THERAPEUTIC_AREA"
+	HasBusinessTherapeuticArea = "HAS_BUSINESS_THERAPEUTIC_AREA"
+	HasCharacteristic          = "HAS_CHARACTERISTIC"
+	HasIntentType              = "HAS_INTENT_TYPE"
+	HasSubType                 = "HAS_SUB_TYPE"
+	HasArm                     = "HAS_ARM"
+	HasDataOriginType          = "HAS_DATA_ORIGIN_TYPE"
+	HasEpoch                   = "HAS_EPOCH"
+	Precedes                   = "PRECEDES"
+	HasElement                 = "HAS_ELEMENT"
+	HasCell                    = "HAS_CELL"
+	InArm                      = "IN_ARM"
+	InEpoch                    = "IN_EPOCH"
+	ContainsElement            = "CONTAINS_ELEMENT"
+	HasTransitionStartRule     = "HAS_TRANSITION_START_RULE"
+	HasTransitionEndRule       = "HAS_TRANSITION_END_RULE"
+	HasEncounter               = "HAS_ENCOUNTER"
+	HasEncounterType           = "HAS_ENCOUNTER_TYPE"
+	HasEnvironmentalSetting    = "HAS_ENVIRONMENTAL_SETTING"
+	HasContactMode             = "HAS_CONTACT_MODE"
+	HasActivity                = "HAS_ACTIVITY"
+	HasChildActivity           = "HAS_CHILD_ACTIVITY"
+	HasDefinedProcedure        = "HAS_DEFINED_PROCEDURE"
+	UsesBioMedicalConcept      = "USES_BIO_MEDICAL_CONCEPT"
+	UsesBCCategory             = "USES_BC_CATEGORY"
+	UsesBCSurrogate            = "USES_BC_SURROGATE"
+	UsesIntervention           = "USES_INTERVENTION"
+	ForPopulation              = "FOR_POPULATION"
+	HasCode                    = "HAS_CODE"
+	HasStandardCode            = "HAS_STANDARD_CODE"
+	HasCodeAlias               = "HAS_CODE_ALIAS"
+	HasAmendment               = "HAS_AMENDMENT"
+	HasPrimaryReason           = "HAS_PRIMARY_REASON"
+	HasSecondaryReason         = "HAS_SECONDARY_REASON"
+	HasEnrollment              = "HAS_ENROLLMENT"
+	HasDate                    = "HAS_DATE"
+	HasQuantity                = "HAS_QUANTITY"
+	HasUnit                    = "HAS_UNIT"
+	HasBioMedicalConcept       = "HAS_BIO_MEDICAL_CONCEPT"
+	HasBioMedicalConceptCode   = "HAS_BIO_MEDICAL_CONCEPT_CODE"
+	HasBCCategory              = "HAS_BC_CATEGORY"
+	HasMember                  = "HAS_MEMBER"
+	HasChildCategory           = "HAS_CHILD_CATEGORY"
+	HasBCSurrogate             = "HAS_BC_SURROGATE"
+	HasOrganization            = "HAS_ORGANIZATION"
+	HasOrganizationType        = "HAS_ORGANIZATION_TYPE"
+	HasLegalAddress            = "HAS_LEGAL_ADDRESS"
+	LocatedIn                  = "LOCATED_IN"
+	HasRole                    = "HAS_ROLE"
+	AssignedTo                 = "ASSIGNED_TO"
+	HasIntervention            = "HAS_INTERVENTION"
+	HasProductDesignation      = "HAS_PRODUCT_DESIGNATION"
+	HasPharmacologicClass      = "HAS_PHARMACOLOGIC_CLASS"
+	HasMinimumResponseDuration = "HAS_MINIMUM_RESPONSE_DURATION"
+	HasAdministration          = "HAS_ADMINISTRATION"
+	HasDose                    = "HAS_DOSE"
+	HasDuration                = "HAS_DURATION"
+	HasRoute                   = "HAS_ROUTE"
+	HasFrequency               = "HAS_FREQUENCY"
+	Administers                = "ADMINISTERS"
+	HasAdministrableProduct    = "HAS_ADMINISTRABLE_PRODUCT"
+	HasDoseForm                = "HAS_DOSE_FORM"
+	HasCondition               = "HAS_CONDITION"
+	HasTitle                   = "HAS_TITLE"
+	HasIdentifier              = "HAS_IDENTIFIER"
+	HasReferenceIdentifier     = "HAS_REFERENCE_IDENTIFIER"
+	HasAbbreviation            = "HAS_ABBREVIATION"
+	HasEligibilityCriterion    = "HAS_ELIGIBILITY_CRITERION"
+	HasCategory                = "HAS_CATEGORY"
+	UsesCriterionItem          = "USES_CRITERION_ITEM"
+	HasNarrativeContent        = "HAS_NARRATIVE_CONTENT"
+	HasObjective               = "HAS_OBJECTIVE"
+	HasEndpoint                = "HAS_ENDPOINT"
+	HasLevel                   = "HAS_LEVEL"
+	HasPopulation              = "HAS_POPULATION"
+	HasPlannedSex              = "HAS_PLANNED_SEX"
+	HasCriterion               = "HAS_CRITERION"
+	HasIndication              = "HAS_INDICATION"
+	HasEstimand                = "HAS_ESTIMAND"
+	HasTimeline                = "HAS_TIMELINE"
+	HasExit                    = "HAS_EXIT"
+	HasInstance                = "HAS_INSTANCE"
+	HasTiming                  = "HAS_TIMING"
+	HasRelativeToFrom          = "HAS_RELATIVE_TO_FROM"
+	EntersAt                   = "ENTERS_AT"
+	Performs                   = "PERFORMS"
+	AtEncounter                = "AT_ENCOUNTER"
+	RunsTimeline               = "RUNS_TIMELINE"
+	ExitsVia                   = "EXITS_VIA"
+	DefaultsTo                 = "DEFAULTS_TO"
+	RelativeFrom               = "RELATIVE_FROM"
+	RelativeTo                 = "RELATIVE_TO"
+	UsesTimeline               = "USES_TIMELINE"
+	ScheduledAt                = "SCHEDULED_AT"
+)
+
+var codeProperties = []string{"code", "codeSystem", "codeSystemVersion", "decode", "instanceType"}
+
+// USDM is the graph the processor writes for a USDM study document.
+var USDM = mustNew(Study,
+	[]Node{
+		{Label: Study, Properties: []string{"name", "description", "label", "instanceType"}},
+		{Label: StudyVersion, Properties: []string{"versionIdentifier", "rationale", "documentVersionId", "instanceType"}},
+		{Label: StudyDesign, Properties: []string{"name", "label", "description", "rationale", "instanceType"}},
+		{Label: StudyDefinitionDocument, Properties: []string{"name", "description", "label", "templateName", "instanceType"}},
+		{Label: StudyDefinitionDocumentVersion, Properties: []string{"version", "instanceType"}},
+		{Label: Arm, Properties: []string{"name", "label", "description", "dataOriginDescription", "instanceType"}},
+		{Label: ArmDataOriginType, Properties: codeProperties},
+		{Label: Epoch, Properties: []string{"name", "label", "description", "previousId", "nextId", "instanceType"}},
+		{Label: StudyElement, Properties: []string{"name", "label", "description", "instanceType"}},
+		{Label: StudyCell, Properties: []string{"armId", "epochId", "instanceType"}},
+		{Label: TransitionRule, Properties: []string{"name", "label", "description", "text", "instanceType"}},
+		{Label: Encounter, Properties: []string{"name", "description", "label", "previousId", "nextId", "scheduledAtId", "instanceType"}},
+		{Label: EncounterType, Properties: codeProperties},
+		{Label: Activity, Properties: []string{"name", "description", "label", "previousId", "nextId", "timelineId", "instanceType"}},
+		{Label: DefinedProcedure, Properties: []string{"name", "description", "label", "procedureType", "studyInterventionId", "instanceType"}},
+		{Label: Code, Properties: codeProperties},
+		{Label: AliasCode, Properties: []string{"instanceType"}},
+		{Label: StudyAmendment, Properties: []string{"name", "description", "label", "number", "summary", "substantialImpact", "previousId", "instanceType"}},
+		{Label: StudyAmendmentReason, Properties: []string{"otherReason", "instanceType"}},
+		{Label: SubjectEnrollment, Properties: []string{"name", "label", "description", "instanceType"}},
+		{Label: GovernanceDate, Properties: []string{"name", "label", "description", "dateValue", "instanceType"}},
+		{Label: Quantity, Properties: []string{"value", "instanceType"}},
+		{Label: BioMedicalConcept, Properties: []string{"name", "label", "description", "reference", "instanceType"}},
+		{Label: BioMedicalConceptCode, Properties: codeProperties},
+		{Label: BCCategory, Properties: []string{"name", "label", "description", "instanceType"}},
+		{Label: BCSurrogate, Properties: []string{"name", "label", "description", "reference", "instanceType"}},
+		{Label: Organization, Properties: []string{"name", "label", "identifier", "identifierScheme", "instanceType"}},
+		{Label: OrganizationType, Properties: codeProperties},
+		{Label: LegalAddress, Properties: []string{"text", "city", "district", "state", "postalCode", "instanceType"}},
+		{Label: Country, Properties: codeProperties},
+		{Label: StudyRole, Properties: []string{"name", "label", "description", "instanceType"}},
+		{Label: StudyIntervention, Properties: []string{"name", "label", "description", "instanceType"}},
+		{Label: Administration, Properties: []string{"name", "label", "description", "administrableProductId", "instanceType"}},
+		{Label: AdministrableProduct, Properties: []string{"name", "label", "description", "instanceType"}},
+		{Label: Condition, Properties: []string{"name", "label", "description", "text", "instanceType"}},
+		{Label: StudyTitle, Properties: []string{"text", "instanceType"}},
+		{Label: StudyIdentifier, Properties: []string{"text", "scopeId", "instanceType"}},
+		{Label: ReferenceIdentifier, Properties: []string{"text", "scopeId", "instanceType"}},
+		{Label: Abbreviation, Properties: []string{"abbreviatedText", "expandedText", "instanceType"}},
+		{Label: EligibilityCriterion, Properties: []string{"name", "label", "description", "identifier", "criterionItemId", "previousId", "nextId", "contextId", "instanceType"}},
+		{Label: EligibilityCriterionItem, Properties: []string{"name", "label", "description", "text", "dictionaryId", "instanceType"}},
+		{Label: NarrativeContentItem, Properties: []string{"name", "text", "instanceType"}},
+		{Label: Objective, Properties: []string{"name", "label", "description", "text", "instanceType"}},
+		{Label: Endpoint, Properties: []string{"name", "label", "description", "text", "purpose", "instanceType"}},
+		{Label: StudyDesignPopulation, Properties: []string{"name", "label", "description", "includesHealthySubjects", "instanceType"}},
+		{Label: Indication, Properties: []string{"name", "label", "description", "isRareDisease", "instanceType"}},
+		{Label: Estimand, Properties: []string{"name", "label", "description", "populationSummary", "analysisPopulationId", "variableOfInterestId", "instanceType"}},
+		{Label: ScheduleTimeline, Properties: []string{"name", "label", "description", "mainTimeline", "entryCondition", "entryId", "instanceType"}},
+		{Label: ScheduleTimelineExit, Properties: []string{"instanceType"}},
+		{Label: ScheduledInstance, Properties: []string{"name", "label", "description", "timelineId", "timelineExitId", "defaultConditionId", "epochId", "encounterId", "instanceType"}},
+		{Label: Timing, Properties: []string{"name", "label", "description", "value", "valueLabel", "relativeFromScheduledInstanceId", "relativeToScheduledInstanceId", "windowLower", "windowUpper", "windowLabel", "instanceType"}},
+	},
+	[]Edge{
+		{From: Study, Field: "versions", Label: HasVersion, To: StudyVersion, Cardinality: ToMany},
+		{From: Study, Field: "documentedBy", Label: DocumentedBy, To: StudyDefinitionDocument, Cardinality: ToMany},
+		{From: StudyDefinitionDocument, Field: "versions", Label: HasDocumentVersion, To: StudyDefinitionDocumentVersion, Cardinality: ToMany},
+
+		{From: StudyVersion, Field: "titles", Label: HasTitle, To: StudyTitle, Cardinality: ToMany},
+		{From: StudyVersion, Field: "studyIdentifiers", Label: HasIdentifier, To: StudyIdentifier, Cardinality: ToMany},
+		{From: StudyVersion, Field: "referenceIdentifiers", Label: HasReferenceIdentifier, To: ReferenceIdentifier, Cardinality: ToMany},
+		{From: StudyVersion, Field: "dateValues", Label: HasDate, To: GovernanceDate, Cardinality: ToMany},
+		{From: StudyVersion, Field: "businessTherapeuticAreas", Label: HasBusinessTherapeuticArea, To: Code, Cardinality: ToMany},
+		{From: StudyVersion, Field: "organizations", Label: HasOrganization, To: Organization, Cardinality: ToMany},
+		{From: StudyVersion, Field: "roles", Label: HasRole, To: StudyRole, Cardinality: ToMany},
+		{From: StudyVersion, Field: "studyInterventions", Label: HasIntervention, To: StudyIntervention, Cardinality: ToMany},
+		{From: StudyVersion, Field: "administrableProducts", Label: HasAdministrableProduct, To: AdministrableProduct, Cardinality: ToMany},
+		{From: StudyVersion, Field: "biomedicalConcepts", Label: HasBioMedicalConcept, To: BioMedicalConcept, Cardinality: ToMany},
+		{From: StudyVersion, Field: "bcCategories", Label: HasBCCategory, To: BCCategory, Cardinality: ToMany},
+		{From: StudyVersion, Field: "bcSurrogates", Label: HasBCSurrogate, To: BCSurrogate, Cardinality: ToMany},
+		{From: StudyVersion, Field: "conditions", Label: HasCondition, To: Condition, Cardinality: ToMany},
+		{From: StudyVersion, Field: "eligibilityCriterionItems", Label: HasEligibilityCriterion, To: EligibilityCriterionItem, Cardinality: ToMany},
+		{From: StudyVersion, Field: "narrativeContentItems", Label: HasNarrativeContent, To: NarrativeContentItem, Cardinality: ToMany},
+		{From: StudyVersion, Field: "abbreviations", Label: HasAbbreviation, To: Abbreviation, Cardinality: ToMany},
+		{From: StudyVersion, Field: "amendments", Label: HasAmendment, To: StudyAmendment, Cardinality: ToMany},
+		{From: StudyVersion, Field: "studyDesigns", Label: IncludesDesign, To: StudyDesign, Cardinality: ToMany},
+
+		{From: StudyTitle, Field: "type", Label: HasType, To: Code, Cardinality: ToOne},
+		{From: ReferenceIdentifier, Field: "type", Label: HasType, To: Code, Cardinality: ToOne},
+		{From: GovernanceDate, Field: "type", Label: HasType, To: Code, Cardinality: ToOne},
+
+		{From: Organization, Field: "type", Label: HasOrganizationType, To: OrganizationType, Cardinality: ToOne},
+		{From: Organization, Field: "legalAddress", Label: HasLegalAddress, To: LegalAddress, Cardinality: ToOne},
+		{From: LegalAddress, Field: "country", Label: LocatedIn, To: Country, Cardinality: ToOne},
+		{From: StudyRole, Field: "code", Label: HasCode, To: Code, Cardinality: ToOne},
+
+		{From: StudyIntervention, Field: "type", Label: HasType, To: Code, Cardinality: ToOne},
+		{From: StudyIntervention, Field: "role", Label: HasRole, To: Code, Cardinality: ToOne},
+		{From: StudyIntervention, Field: "codes", Label: HasCode, To: Code, Cardinality: ToMany},
+		{From: StudyIntervention, Field: "productDesignation", Label: HasProductDesignation, To: Code, Cardinality: ToOne},
+		{From: StudyIntervention, Field: "pharmacologicClass", Label: HasPharmacologicClass, To: Code, Cardinality: ToOne},
+		{From: StudyIntervention, Field: "minimumResponseDuration", Label: HasMinimumResponseDuration, To: Quantity, Cardinality: ToOne},
+		{From: StudyIntervention, Field: "administrations", Label: HasAdministration, To: Administration, Cardinality: ToMany},
+		{From: Administration, Field: "dose", Label: HasDose, To: Quantity, Cardinality: ToOne},
+		{From: Administration, Field: "duration", Label: HasDuration, To: Quantity, Cardinality: ToOne},
+		{From: Administration, Field: "route", Label: HasRoute, To: Code, Cardinality: ToOne},
+		{From: Administration, Field: "frequency", Label: HasFrequency, To: Code, Cardinality: ToOne},
+		{From: AdministrableProduct, Field: "administrableDoseForm", Label: HasDoseForm, To: AliasCode, Cardinality: ToOne},
+		{From: AdministrableProduct, Field: "pharmacologicClass", Label: HasPharmacologicClass, To: Code, Cardinality: ToOne},
+		{From: Quantity, Field: "unit", Label: HasUnit, To: Code, Cardinality: ToOne},
+		{From: AliasCode, Field: "standardCode", Label: HasStandardCode, To: Code, Cardinality: ToOne},
+		{From: AliasCode, Field: "standardCodeAliases", Label: HasCodeAlias, To: Code, Cardinality: ToMany},
+
+		{From: BioMedicalConcept, Field: "code", Label: HasBioMedicalConceptCode, To: BioMedicalConceptCode, Cardinality: ToOne},
+		{From: BCCategory, Field: "code", Label: HasCode, To: Code, Cardinality: ToOne},
+
+		{From: StudyAmendment, Field: "primaryReason", Label: HasPrimaryReason, To: StudyAmendmentReason, Cardinality: ToOne},
+		{From: StudyAmendment, Field: "secondaryReasons", Label: HasSecondaryReason, To: StudyAmendmentReason, Cardinality: ToMany},
+		{From: StudyAmendment, Field: "enrollments", Label: HasEnrollment, To: SubjectEnrollment, Cardinality: ToMany},
+		{From: StudyAmendment, Field: "dateValues", Label: HasDate, To: GovernanceDate, Cardinality: ToMany},
+		{From: StudyAmendmentReason, Field: "code", Label: HasCode, To: Code, Cardinality: ToOne},
+		{From: SubjectEnrollment, Field: "quantity", Label: HasQuantity, To: Quantity, Cardinality: ToOne},
+
+		{From: StudyDesign, Field: "studyType", Label: HasType, To: Code, Cardinality: ToOne},
+		{From: StudyDesign, Field: "studyPhase", Label: HasPhase, To: AliasCode, Cardinality: ToOne},
+		{From: StudyDesign, Field: "model", Label: HasModel, To: Code, Cardinality: ToOne},
+		{From: StudyDesign, Field: "blindingSchema", Label: HasBlindingSchema, To: AliasCode, Cardinality: ToOne},
+		{From: StudyDesign, Field: "therapeuticAreas", Label: HasTherapeuticArea, To: Code, Cardinality: ToMany},
+		{From: StudyDesign, Field: "characteristics", Label: HasCharacteristic, To: Code, Cardinality: ToMany},
+		{From: StudyDesign, Field: "intentTypes", Label: HasIntentType, To: Code, Cardinality: ToMany},
+		{From: StudyDesign, Field: "subTypes", Label: HasSubType, To: Code, Cardinality: ToMany},
+		{From: StudyDesign, Field: "arms", Label: HasArm, To: Arm, Cardinality: ToMany},
+		{From: StudyDesign, Field: "epochs", Label: HasEpoch, To: Epoch, Cardinality: ToMany},
+		{From: StudyDesign, Field: "elements", Label: HasElement, To: StudyElement, Cardinality: ToMany},
+		{From: StudyDesign, Field: "studyCells", Label: HasCell, To: StudyCell, Cardinality: ToMany},
+		{From: StudyDesign, Field: "encounters", Label: HasEncounter, To: Encounter, Cardinality: ToMany},
+		{From: StudyDesign, Field: "activities", Label: HasActivity, To: Activity, Cardinality: ToMany},
+		{From: StudyDesign, Field: "objectives", Label: HasObjective, To: Objective, Cardinality: ToMany},
+		{From: StudyDesign, Field: "population", Label: HasPopulation, To: StudyDesignPopulation, Cardinality: ToOne},
+		{From: StudyDesign, Field: "eligibilityCriteria", Label: HasEligibilityCriterion, To: EligibilityCriterion, Cardinality: ToMany},
+		{From: StudyDesign, Field: "indications", Label: HasIndication, To: Indication, Cardinality: ToMany},
+		{From: StudyDesign, Field: "estimands", Label: HasEstimand, To: Estimand, Cardinality: ToMany},
+
+		{From: Arm, Field: "type", Label: HasType, To: Code, Cardinality: ToOne},
+		{From: Arm, Field: "dataOriginType", Label: HasDataOriginType, To: ArmDataOriginType, Cardinality: ToOne},
+		{From: Epoch, Field: "type", Label: HasType, To: Code, Cardinality: ToOne},
+		{From: StudyElement, Field: "transitionStartRule", Label: HasTransitionStartRule, To: TransitionRule, Cardinality: ToOne},
+		{From: StudyElement, Field: "transitionEndRule", Label: HasTransitionEndRule, To: TransitionRule, Cardinality: ToOne},
+
+		{From: Encounter, Field: "type", Label: HasEncounterType, To: EncounterType, Cardinality: ToOne},
+		{From: Encounter, Field: "environmentalSettings", Label: HasEnvironmentalSetting, To: Code, Cardinality: ToMany},
+		{From: Encounter, Field: "contactModes", Label: HasContactMode, To: Code, Cardinality: ToMany},
+		{From: Encounter, Field: "transitionStartRule", Label: HasTransitionStartRule, To: TransitionRule, Cardinality: ToOne},
+		{From: Encounter, Field: "transitionEndRule", Label: HasTransitionEndRule, To: TransitionRule, Cardinality: ToOne},
+		{From: Activity, Field: "definedProcedures", Label: HasDefinedProcedure, To: DefinedProcedure, Cardinality: ToMany},
+		{From: DefinedProcedure, Field: "code", Label: HasCode, To: Code, Cardinality: ToOne},
+
+		{From: Objective, Field: "level", Label: HasLevel, To: Code, Cardinality: ToOne},
+		{From: Objective, Field: "endpoints", Label: HasEndpoint, To: Endpoint, Cardinality: ToMany},
+		{From: Endpoint, Field: "level", Label: HasLevel, To: Code, Cardinality: ToOne},
+		{From: StudyDesignPopulation, Field: "plannedSex", Label: HasPlannedSex, To: Code, Cardinality: ToMany},
+		{From: EligibilityCriterion, Field: "category", Label: HasCategory, To: Code, Cardinality: ToOne},
+		{From: Indication, Field: "codes", Label: HasCode, To: Code, Cardinality: ToMany},
+
+		{From: StudyDesign, Field: "scheduleTimelines", Label: HasTimeline, To: ScheduleTimeline, Cardinality: ToMany},
+		{From: ScheduleTimeline, Field: "exits", Label: HasExit, To: ScheduleTimelineExit, Cardinality: ToMany},
+		{From: ScheduleTimeline, Field: "timings", Label: HasTiming, To: Timing, Cardinality: ToMany},
+		{From: ScheduleTimeline, Field: "instances", Label: HasInstance, To: ScheduledInstance, Cardinality: ToMany},
+		{From: Timing, Field: "type", Label: HasType, To: Code, Cardinality: ToOne},
+		{From: Timing, Field: "relativeToFrom", Label: HasRelativeToFrom, To: Code, Cardinality: ToOne},
+	},
+	[]Reference{
+		{From: Epoch, Property: "previousId", Field: "precededBy", Label: Precedes, To: Epoch, Inverse: true},
+		{From: Epoch, Property: "nextId", Field: "precedes", Label: Precedes, To: Epoch},
+		{From: StudyCell, Property: "armId", Field: "arm", Label: InArm, To: Arm},
+		{From: StudyCell, Property: "epochId", Field: "epoch", Label: InEpoch, To: Epoch},
+		{From: StudyCell, Property: "elementIds", Field: "elements", Label: ContainsElement, To: StudyElement, Cardinality: ToMany},
+		{From: Activity, Property: "childIds", Label: HasChildActivity, To: Activity, Cardinality: ToMany},
+		{From: Activity, Property: "biomedicalConceptIds", Label: UsesBioMedicalConcept, To: BioMedicalConcept, Cardinality: ToMany},
+		{From: Activity, Property: "bcCategoryIds", Label: UsesBCCategory, To: BCCategory, Cardinality: ToMany},
+		{From: Activity, Property: "bcSurrogateIds", Label: UsesBCSurrogate, To: BCSurrogate, Cardinality: ToMany},
+		{From: BCCategory, Property: "memberIds", Label: HasMember, To: BioMedicalConcept, Cardinality: ToMany},
+		{From: BCCategory, Property: "childIds", Label: HasChildCategory, To: BCCategory, Cardinality: ToMany},
+		{From: StudyRole, Property: "organizationIds", Label: AssignedTo, To: Organization, Cardinality: ToMany},
+		{From: StudyDesign, Property: "studyInterventionIds", Label: UsesIntervention, To: StudyIntervention, Cardinality: ToMany},
+		{From: StudyElement, Property: "studyInterventionIds", Label: UsesIntervention, To: StudyIntervention, Cardinality: ToMany},
+		{From: Estimand, Property: "interventionIds", Label: UsesIntervention, To: StudyIntervention, Cardinality: ToMany},
+		{From: Arm, Property: "populationIds", Label: ForPopulation, To: StudyDesignPopulation, Cardinality: ToMany},
+		{From: Administration, Property: "administrableProductId", Label: Administers, To: AdministrableProduct},
+		{From: StudyDesignPopulation, Property: "criterionIds", Label: HasCriterion, To: EligibilityCriterion, Cardinality: ToMany},
+		{From: EligibilityCriterion, Property: "criterionItemId", Label: UsesCriterionItem, To: EligibilityCriterionItem},
+		{From: ScheduleTimeline, Property: "entryId", Field: "entry", Label: EntersAt, To: ScheduledInstance},
+		{From: ScheduledInstance, Property: "activityIds", Field: "activities", Label: Performs, To: Activity, Cardinality: ToMany},
+		{From: ScheduledInstance, Property: "encounterId", Field: "encounter", Label: AtEncounter, To: Encounter},
+		{From: ScheduledInstance, Property: "epochId", Field: "epoch", Label: InEpoch, To: Epoch},
+		{From: ScheduledInstance, Property: "timelineId", Field: "timeline", Label: RunsTimeline, To: ScheduleTimeline},
+		{From: ScheduledInstance, Property: "timelineExitId", Label: ExitsVia, To: ScheduleTimelineExit},
+		{From: ScheduledInstance, Property: "defaultConditionId", Label: DefaultsTo, To: ScheduledInstance},
+		{From: Timing, Property: "relativeFromScheduledInstanceId", Field: "relativeFrom", Label: RelativeFrom, To: ScheduledInstance},
+		{From: Timing, Property: "relativeToScheduledInstanceId", Field: "relativeTo", Label: RelativeTo, To: ScheduledInstance},
+		{From: Activity, Property: "timelineId", Field: "timeline", Label: UsesTimeline, To: ScheduleTimeline},
+		{From: Encounter, Property: "scheduledAtId", Field: "scheduledAt", Label: ScheduledAt, To: Timing},
+	},
+	[]Backlink{
+		{From: StudyVersion, Field: "study", Label: HasVersion, To: Study},
+		{From: StudyDesign, Field: "studyVersion", Label: IncludesDesign, To: StudyVersion},
+		{From: Arm, Field: "studyDesign", Label: HasArm, To: StudyDesign},
+		{From: Epoch, Field: "studyDesign", Label: HasEpoch, To: StudyDesign},
+		{From: StudyElement, Field: "studyDesign", Label: HasElement, To: StudyDesign},
+		{From: StudyCell, Field: "studyDesign", Label: HasCell, To: StudyDesign},
+		{From: Encounter, Field: "studyDesigns", Label: HasEncounter, To: StudyDesign, Cardinality: ToMany},
+		{From: Activity, Field: "studyDesigns", Label: HasActivity, To: StudyDesign, Cardinality: ToMany},
+		{From: ScheduleTimeline, Field: "studyDesign", Label: HasTimeline, To: StudyDesign},
+	},
+)
+
+func mustNew(root string, nodes []Node, edges []Edge, refs []Reference, backlinks []Backlink) *Schema {
+	s, err := New(root, nodes, edges, refs, backlinks)
+	if err != nil {
+		panic("graphschema: " + err.Error())
+	}
+	return s
+}
@@ -0,0 +1,123 @@
+// Package intake accepts an SDR payload onto DataIngestionQueue: it
+// validates the payload, records the submission and sends the message the
+// processor works from. sdrHandler calls it for one REST request at a
+// time and sdrBulkIngest for every study in a file dropped into the
+// ingestion bucket.
+package intake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/submission"
+	"github.com/ankit-lilly/dtd-go-backend/internal/usdm"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// Queue sends an accepted payload to the processor with the submission
+// it was recorded as.
+type Queue interface {
+	Send(ctx context.Context, record submission.Record, body []byte) (messageID string, err error)
+}
+
+type Status string
+
+const (
+	// Accepted payloads are queued under Result.SubmissionID.
+	Accepted Status = "accepted"
+	// Rejected payloads are not valid SDRs; Violations or Error says why.
+	Rejected Status = "rejected"
+	// Failed payloads were valid but could not be recorded or queued.
+	Failed Status = "failed"
+)
+
+// Payload is the part of an SDR payload intake reads itself.
+type Payload struct {
+	Study       models.Study `json:"study"`
+	UsdmVersion string       `json:"usdmVersion"`
+}
+
+// Result is what became of one payload.
+type Result struct {
+	Status       Status           `json:"status"`
+	SubmissionID string           `json:"submissionId,omitempty"`
+	StudyID      string           `json:"studyId,omitempty"`
+	UsdmVersion  string           `json:"usdmVersion,omitempty"`
+	Violations   []usdm.Violation `json:"violations,omitempty"`
+	Error        string           `json:"error,omitempty"`
+}
+
+type Intake struct {
+	store submission.Store
+	queue Queue
+}
+
+func New(store submission.Store, queue Queue) *Intake {
+	return &Intake{store: store, queue: queue}
+}
+
+// Submit validates body and, if it is a valid SDR, records a queued
+// submission for it and sends it to the queue. A payload that is not JSON
+// is rejected with Error set and no Violations.
+func (in *Intake) Submit(ctx context.Context, body []byte) Result {
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return Result{Status: Rejected, Error: fmt.Sprintf("invalid JSON payload: %v", err)}
+	}
+
+	result := Result{StudyID: payload.Study.ID, UsdmVersion: payload.UsdmVersion}
+
+	violations, err := usdm.Validate(body)
+	if err != nil {
+		result.Status = Failed
+		result.Error = fmt.Sprintf("failed to validate SDR: %v", err)
+		return result
+	}
+	if len(violations) > 0 {
+		log.Printf("Rejected SDR %s with %d USDM violations", payload.Study.ID, len(violations))
+		result.Status = Rejected
+		result.Violations = violations
+		return result
+	}
+
+	submissionID, err := submission.NewID()
+	if err != nil {
+		result.Status = Failed
+		result.Error = err.Error()
+		return result
+	}
+
+	// The record exists before the message does, so the processor never
+	// sees a submission it cannot update.
+	record := submission.Record{
+		ID:          submissionID,
+		StudyID:     payload.Study.ID,
+		UsdmVersion: payload.UsdmVersion,
+		Status:      submission.Queued,
+		SubmittedAt: time.Now().UTC(),
+	}
+	err = in.store.Create(ctx, record)
+	if err != nil {
+		result.Status = Failed
+		result.Error = fmt.Sprintf("failed to record submission: %v", err)
+		return result
+	}
+	result.SubmissionID = submissionID
+
+	messageID, err := in.queue.Send(ctx, record, body)
+	if err != nil {
+		if markErr := in.store.MarkFailed(ctx, submissionID, err); markErr != nil {
+			log.Printf("Failed to mark submission %s as failed: %v", submissionID, markErr)
+		}
+		result.Status = Failed
+		result.Error = fmt.Sprintf("failed to queue submission: %v", err)
+		return result
+	}
+
+	log.Printf("Message sent to SQS with ID: %s for submission %s", messageID, submissionID)
+	result.Status = Accepted
+	return result
+}
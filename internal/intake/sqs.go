@@ -0,0 +1,75 @@
+package intake
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/claimcheck"
+	"github.com/ankit-lilly/dtd-go-backend/internal/submission"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// QueueURLEnv is the environment variable holding the DataIngestionQueue
+// URL.
+const QueueURLEnv = "QUEUE_URL"
+
+// SQSQueue is the Queue backed by DataIngestionQueue. Bodies larger than
+// claimcheck.MaxInlineSize are stored in payloads and sent by reference.
+type SQSQueue struct {
+	client   *sqs.Client
+	url      string
+	payloads *claimcheck.Store
+}
+
+func NewSQSQueue(cfg aws.Config, url string, payloads *claimcheck.Store) *SQSQueue {
+	return &SQSQueue{client: sqs.NewFromConfig(cfg), url: url, payloads: payloads}
+}
+
+func (q *SQSQueue) Send(ctx context.Context, record submission.Record, body []byte) (string, error) {
+	submissionID := record.ID
+	attributes := map[string]types.MessageAttributeValue{
+		submission.MessageAttribute: {
+			DataType:    aws.String("String"),
+			StringValue: aws.String(submissionID),
+		},
+		submission.SubmittedAtAttribute: {
+			DataType:    aws.String("String"),
+			StringValue: aws.String(record.SubmittedAt.Format(time.RFC3339Nano)),
+		},
+	}
+	if record.StudyID != "" {
+		attributes[submission.StudyIDAttribute] = types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(record.StudyID),
+		}
+	}
+
+	messageBody := string(body)
+	if len(body) > claimcheck.MaxInlineSize {
+		location, err := q.payloads.Put(ctx, submissionID, body)
+		if err != nil {
+			return "", err
+		}
+		log.Printf("Payload of submission %s is %d bytes, sending it by reference to %s", submissionID, len(body), location)
+
+		attributes[claimcheck.MessageAttribute] = types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(location),
+		}
+		messageBody = location
+	}
+
+	output, err := q.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          aws.String(q.url),
+		MessageBody:       aws.String(messageBody),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to send message to SQS: %w", err)
+	}
+	return aws.ToString(output.MessageId), nil
+}
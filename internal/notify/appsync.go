@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// The mutations select every field, since a subscription can only deliver
+// the fields its mutation returned.
+const (
+	publishStudyIngestion = `mutation PublishStudyIngestion($input: StudyIngestionInput!) {
+  publishStudyIngestion(input: $input) { eventType studyId versionIds submissionId revision ingestedAt }
+}`
+	publishSubmissionStatus = `mutation PublishSubmissionStatus($input: SubmissionStatusInput!) {
+  publishSubmissionStatus(input: $input) { submissionId status revision error updatedAt }
+}`
+)
+
+// AppSyncNotifier runs the mutations against the AppSync API, signed with
+// the Lambda's IAM credentials.
+type AppSyncNotifier struct {
+	client *http.Client
+	cfg    aws.Config
+	signer *v4.Signer
+	url    string
+}
+
+func NewAppSyncNotifier(cfg aws.Config, url string) *AppSyncNotifier {
+	return &AppSyncNotifier{
+		client: &http.Client{Timeout: 10 * time.Second},
+		cfg:    cfg,
+		signer: v4.NewSigner(),
+		url:    url,
+	}
+}
+
+func (n *AppSyncNotifier) StudyIngested(ctx context.Context, ingestion StudyIngestion) error {
+	if err := n.mutate(ctx, publishStudyIngestion, ingestion); err != nil {
+		return fmt.Errorf("failed to notify ingestion of study %s: %w", ingestion.StudyID, err)
+	}
+	return nil
+}
+
+func (n *AppSyncNotifier) SubmissionStatusChanged(ctx context.Context, change StatusChange) error {
+	if err := n.mutate(ctx, publishSubmissionStatus, change); err != nil {
+		return fmt.Errorf("failed to notify status of submission %s: %w", change.SubmissionID, err)
+	}
+	return nil
+}
+
+func (n *AppSyncNotifier) mutate(ctx context.Context, query string, input any) error {
+	body, err := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": map[string]any{"input": input},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal mutation: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	creds, err := n.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := n.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "appsync", n.cfg.Region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("AppSync returned %s: %s", resp.Status, respBody)
+	}
+
+	// AppSync reports a failed mutation with 200 and the errors.
+	var result struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		messages := make([]string, len(result.Errors))
+		for i, e := range result.Errors {
+			messages[i] = e.Message
+		}
+		return fmt.Errorf("mutation failed: %s", strings.Join(messages, "; "))
+	}
+	return nil
+}
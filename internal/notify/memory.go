@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// MemoryNotifier is a Notifier that collects notifications rather than
+// running the AppSync mutations, keeping ingestions and status changes
+// apart.
+type MemoryNotifier struct {
+	mu         sync.Mutex
+	ingestions []StudyIngestion
+	changes    []StatusChange
+}
+
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+// Ingestions returns the study ingestions notified so far, oldest first.
+func (n *MemoryNotifier) Ingestions() []StudyIngestion {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return slices.Clone(n.ingestions)
+}
+
+// StatusChanges returns the status changes notified so far, oldest first.
+func (n *MemoryNotifier) StatusChanges() []StatusChange {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return slices.Clone(n.changes)
+}
+
+func (n *MemoryNotifier) StudyIngested(_ context.Context, ingestion StudyIngestion) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.ingestions = append(n.ingestions, ingestion)
+	return nil
+}
+
+func (n *MemoryNotifier) SubmissionStatusChanged(_ context.Context, change StatusChange) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.changes = append(n.changes, change)
+	return nil
+}
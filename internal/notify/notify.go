@@ -0,0 +1,57 @@
+// Package notify pushes ingestion progress to GraphQL subscribers. AppSync
+// only delivers a subscription when one of the mutations it subscribes to
+// runs, so sdrProcessor calls publishStudyIngestion once a study is
+// written and publishSubmissionStatus whenever a submission's status
+// changes; onStudyIngested and onSubmissionStatusChanged relay them.
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/studyevent"
+	"github.com/ankit-lilly/dtd-go-backend/internal/submission"
+)
+
+// GraphQLURLEnv is the environment variable holding the AppSync GraphQL
+// endpoint.
+const GraphQLURLEnv = "GRAPHQL_URL"
+
+// StudyIngestion is what onStudyIngested delivers.
+type StudyIngestion struct {
+	EventType    studyevent.Type `json:"eventType"`
+	StudyID      string          `json:"studyId"`
+	VersionIDs   []string        `json:"versionIds"`
+	SubmissionID string          `json:"submissionId,omitempty"`
+	Revision     int             `json:"revision"`
+	IngestedAt   string          `json:"ingestedAt"`
+}
+
+// StatusChange is what onSubmissionStatusChanged delivers. Revision is
+// set for succeeded submissions and Error for failed and rejected ones.
+type StatusChange struct {
+	SubmissionID string            `json:"submissionId"`
+	Status       submission.Status `json:"status"`
+	Revision     int               `json:"revision,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	UpdatedAt    string            `json:"updatedAt"`
+}
+
+// Notifier runs the mutations subscribers listen to.
+type Notifier interface {
+	StudyIngested(ctx context.Context, ingestion StudyIngestion) error
+	SubmissionStatusChanged(ctx context.Context, change StatusChange) error
+}
+
+// IngestionOf returns the StudyIngestion announcing the same write as
+// event, which must be a StudyIngested or StudyUpdated event.
+func IngestionOf(event studyevent.Event) StudyIngestion {
+	return StudyIngestion{
+		EventType:    event.Type,
+		StudyID:      event.StudyID,
+		VersionIDs:   event.VersionIDs,
+		SubmissionID: event.SubmissionID,
+		Revision:     event.Revision,
+		IngestedAt:   event.Time.UTC().Format(time.RFC3339Nano),
+	}
+}
@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/submission"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// StatusStore is a submission.Store that notifies every status change it
+// records. The change is recorded by then, so a failed notification is
+// logged rather than returned.
+type StatusStore struct {
+	submission.Store
+	notifier Notifier
+}
+
+func NewStatusStore(store submission.Store, notifier Notifier) *StatusStore {
+	return &StatusStore{Store: store, notifier: notifier}
+}
+
+func (s *StatusStore) MarkProcessing(ctx context.Context, id string) error {
+	if err := s.Store.MarkProcessing(ctx, id); err != nil {
+		return err
+	}
+	s.notify(ctx, StatusChange{SubmissionID: id, Status: submission.Processing})
+	return nil
+}
+
+func (s *StatusStore) MarkSucceeded(ctx context.Context, id string, revision int, changes models.ChangeSummary) error {
+	if err := s.Store.MarkSucceeded(ctx, id, revision, changes); err != nil {
+		return err
+	}
+	s.notify(ctx, StatusChange{SubmissionID: id, Status: submission.Succeeded, Revision: revision})
+	return nil
+}
+
+func (s *StatusStore) MarkFailed(ctx context.Context, id string, cause error) error {
+	if err := s.Store.MarkFailed(ctx, id, cause); err != nil {
+		return err
+	}
+	s.notify(ctx, StatusChange{SubmissionID: id, Status: submission.Failed, Error: cause.Error()})
+	return nil
+}
+
+func (s *StatusStore) MarkRejected(ctx context.Context, id string, cause error) error {
+	if err := s.Store.MarkRejected(ctx, id, cause); err != nil {
+		return err
+	}
+	s.notify(ctx, StatusChange{SubmissionID: id, Status: submission.Rejected, Error: cause.Error()})
+	return nil
+}
+
+func (s *StatusStore) notify(ctx context.Context, change StatusChange) {
+	change.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	if err := s.notifier.SubmissionStatusChanged(ctx, change); err != nil {
+		log.Printf("Failed to notify %s status of submission %s: %v", change.Status, change.SubmissionID, err)
+	}
+}
@@ -0,0 +1,68 @@
+package rdf
+
+import "strings"
+
+// JSONLD returns g as a JSON-LD document: a node object per resource in
+// @graph, with the vocabularies' prefixes in the @context.
+func (g Graph) JSONLD() map[string]any {
+	context := make(map[string]any, len(prefixes))
+	for _, p := range prefixes {
+		context[p.prefix] = p.namespace
+	}
+
+	graph := []map[string]any{}
+	var node map[string]any
+	for _, t := range g.Triples {
+		if node == nil || node["@id"] != t.Subject {
+			node = map[string]any{"@id": t.Subject}
+			graph = append(graph, node)
+		}
+
+		key, value := compactIRI(t.Predicate), jsonLDTerm(t.Object)
+		if t.Predicate == rdfType {
+			key, value = "@type", compactIRI(t.Object.IRI)
+		}
+		switch existing := node[key].(type) {
+		case nil:
+			node[key] = value
+		case []any:
+			node[key] = append(existing, value)
+		default:
+			node[key] = []any{existing, value}
+		}
+	}
+
+	return map[string]any{
+		"@context": context,
+		"@graph":   graph,
+	}
+}
+
+func jsonLDTerm(t Term) any {
+	if t.IRI != "" {
+		return map[string]any{"@id": compactIRI(t.IRI)}
+	}
+	var f float64
+	switch v := t.Value.(type) {
+	case float32:
+		f = float64(v)
+	case float64:
+		f = v
+	default:
+		return v
+	}
+	// A JSON number with a fraction is already an xsd:double, but NaN and
+	// the infinities have no JSON number, so doubles are written typed.
+	return map[string]any{"@value": doubleLexical(f), "@type": "xsd:double"}
+}
+
+// compactIRI writes iri as a compact IRI if it is in one of the prefixed
+// namespaces.
+func compactIRI(iri string) string {
+	for _, p := range prefixes {
+		if local, ok := strings.CutPrefix(iri, p.namespace); ok && localName.MatchString(local) {
+			return p.prefix + ":" + local
+		}
+	}
+	return iri
+}
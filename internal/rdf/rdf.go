@@ -0,0 +1,272 @@
+// Package rdf serializes the subgraph SaveStudyToGraph writes for a study
+// as linked data, in Turtle or JSON-LD. Every node becomes a resource typed
+// by its label in the USDM vocabulary, its properties become literals and
+// its edges links, named by the document field or id-valued property the
+// writer made them from. Codes from NCI Thesaurus, which CDISC controlled
+// terminology is drawn from, link to the NCIt concept.
+package rdf
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+)
+
+// BaseIRIEnv is the environment variable holding the IRI study resources
+// are named under. Without it they are named under DefaultBaseIRI.
+const BaseIRIEnv = "RDF_BASE_IRI"
+
+const DefaultBaseIRI = "urn:dtd-go-backend:study:"
+
+// Namespaces of the vocabularies the serializations use.
+const (
+	USDM = "http://www.cdisc.org/USDM#"
+	NCIt = "http://ncicb.nci.nih.gov/xml/owl/EVS/Thesaurus.owl#"
+	RDF  = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	XSD  = "http://www.w3.org/2001/XMLSchema#"
+)
+
+// prefixes are the namespaces written as prefixes, in the order they are
+// declared.
+var prefixes = []struct{ prefix, namespace string }{
+	{"usdm", USDM},
+	{"ncit", NCIt},
+	{"rdf", RDF},
+	{"xsd", XSD},
+}
+
+const rdfType = RDF + "type"
+
+// Format is a serialization of a study graph.
+type Format string
+
+const (
+	Turtle Format = "turtle"
+	JSONLD Format = "jsonld"
+)
+
+// ParseFormat reads a format as "turtle" or "jsonld", in any case. An
+// empty format is Turtle.
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case "", Turtle, "ttl":
+		return Turtle, nil
+	case JSONLD, "json-ld":
+		return JSONLD, nil
+	default:
+		return "", fmt.Errorf("unsupported RDF format %q, supported formats are turtle and jsonld", s)
+	}
+}
+
+// ContentType is the media type of f.
+func (f Format) ContentType() string {
+	if f == JSONLD {
+		return "application/ld+json"
+	}
+	return "text/turtle"
+}
+
+// Extension is the file extension of f.
+func (f Format) Extension() string {
+	if f == JSONLD {
+		return "jsonld"
+	}
+	return "ttl"
+}
+
+// Term is the object of a triple: an IRI if IRI is set, else the literal
+// Value, a string, bool or number.
+type Term struct {
+	IRI   string
+	Value any
+}
+
+type Triple struct {
+	Subject   string
+	Predicate string
+	Object    Term
+}
+
+// Graph is the triples of one study, ordered by subject with the study
+// first, then by predicate with rdf:type first.
+type Graph struct {
+	Triples []Triple
+}
+
+// FromSubgraph returns the graph of the nodes and edges written for scope,
+// as graphread.Subgraph reads them. Resources are named under base.
+func FromSubgraph(scope string, nodes map[string]graphschema.StoredNode, edges []graphschema.StoredEdge, base string) Graph {
+	schema := graphschema.USDM
+	if base == "" {
+		base = DefaultBaseIRI
+	}
+
+	iris := make(map[string]string, len(nodes))
+	for key, node := range nodes {
+		iris[key] = resourceIRI(base, scope, schema, node)
+	}
+	root := iris[scope]
+
+	var triples []Triple
+	for key, node := range nodes {
+		subject := iris[key]
+		triples = append(triples, Triple{subject, rdfType, Term{IRI: USDM + node.Label}})
+		for property, value := range node.Properties {
+			// Id-valued properties are written as the links below.
+			if _, ref := schema.ReferenceProperty(node.Label, property); ref || graphschema.Bookkeeping(property) {
+				continue
+			}
+			for _, v := range values(value) {
+				object := Term{Value: v}
+				if property == "code" {
+					if concept := nciConcept(node.Properties); concept != "" {
+						object = Term{IRI: concept}
+					}
+				}
+				triples = append(triples, Triple{subject, USDM + property, object})
+			}
+		}
+	}
+
+	for _, e := range edges {
+		from, to := iris[e.From], iris[e.To]
+		if from == "" || to == "" {
+			continue
+		}
+		for _, l := range links(schema, nodes[e.From].Label, e.Label, nodes[e.To].Label) {
+			if l.inverse {
+				triples = append(triples, Triple{to, USDM + l.property, Term{IRI: from}})
+			} else {
+				triples = append(triples, Triple{from, USDM + l.property, Term{IRI: to}})
+			}
+		}
+	}
+
+	slices.SortFunc(triples, func(a, b Triple) int {
+		if a.Subject != b.Subject {
+			if a.Subject == root || b.Subject == root {
+				return boolOrder(a.Subject == root, b.Subject == root)
+			}
+			return cmp.Compare(a.Subject, b.Subject)
+		}
+		if a.Predicate != b.Predicate {
+			if a.Predicate == rdfType || b.Predicate == rdfType {
+				return boolOrder(a.Predicate == rdfType, b.Predicate == rdfType)
+			}
+			return cmp.Compare(a.Predicate, b.Predicate)
+		}
+		return cmp.Compare(fmt.Sprint(a.Object.IRI, a.Object.Value), fmt.Sprint(b.Object.IRI, b.Object.Value))
+	})
+	return Graph{Triples: slices.Compact(triples)}
+}
+
+// boolOrder orders the item that is true before the one that is not.
+func boolOrder(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// resourceIRI names node under base: the study by its scope, a study id
+// or revision snapshot key, and every other node by its label and id
+// within the study.
+func resourceIRI(base, scope string, schema *graphschema.Schema, node graphschema.StoredNode) string {
+	iri := base + url.PathEscape(scope)
+	if node.Label == schema.Root {
+		return iri
+	}
+	id, _ := node.Properties["id"].(string)
+	return iri + "/" + url.PathEscape(node.Label) + "/" + url.PathEscape(id)
+}
+
+type edgeLink struct {
+	property string
+	inverse  bool
+}
+
+// links returns what an edge between nodes of the labels from and to
+// stands for: the document field or id-valued properties it was written
+// from. An inverse link is held by the node the edge points at, as an
+// epoch's previousId is.
+func links(schema *graphschema.Schema, from, label, to string) []edgeLink {
+	var found []edgeLink
+	for _, e := range schema.Edges {
+		if e.From == from && e.Label == label && e.To == to {
+			found = append(found, edgeLink{property: e.Field})
+		}
+	}
+	for _, r := range schema.References {
+		switch {
+		case r.Label != label:
+		case !r.Inverse && r.From == from && r.To == to:
+			found = append(found, edgeLink{property: r.Property})
+		case r.Inverse && r.From == to && r.To == from:
+			found = append(found, edgeLink{property: r.Property, inverse: true})
+		}
+	}
+	return found
+}
+
+// values lists the values of a property, one per triple.
+func values(value any) []any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	case []string:
+		list := make([]any, len(v))
+		for i, s := range v {
+			list[i] = s
+		}
+		return list
+	default:
+		return []any{v}
+	}
+}
+
+var nciCode = regexp.MustCompile(`^C[0-9]+$`)
+
+// nciConcept returns the NCIt concept IRI of a code node, or "" if its
+// code is not an NCIt concept code. CDISC controlled terminology codes are
+// NCIt codes, whichever of the two the codeSystem names.
+func nciConcept(properties map[string]any) string {
+	code, _ := properties["code"].(string)
+	system, _ := properties["codeSystem"].(string)
+	if !nciCode.MatchString(code) {
+		return ""
+	}
+	system = strings.ToLower(system)
+	for _, name := range []string{"cdisc", "ncit", "nci thesaurus", "nci.nih.gov"} {
+		if strings.Contains(system, name) {
+			return NCIt + code
+		}
+	}
+	if system == "nci" {
+		return NCIt + code
+	}
+	return ""
+}
+
+// Serialize writes g in format f.
+func (g Graph) Serialize(f Format) ([]byte, error) {
+	if f == JSONLD {
+		b, err := json.Marshal(g.JSONLD())
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal JSON-LD: %w", err)
+		}
+		return b, nil
+	}
+	return []byte(g.Turtle()), nil
+}
@@ -0,0 +1,198 @@
+package rdf
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+)
+
+const base = "http://example.org/"
+
+// subgraph is a study with two epochs, the second linked back to the first
+// by its previousId, each typed by a code; one code is an NCIt concept and
+// one is not.
+func subgraph() (map[string]graphschema.StoredNode, []graphschema.StoredEdge) {
+	nodes := map[string]graphschema.StoredNode{
+		"S1": {Label: graphschema.Study, Properties: map[string]any{
+			"id": "S1", "key": "S1", "studyId": "S1",
+			"name":  "Study \"One\"\nline",
+			"label": []any{"b", "a"},
+		}},
+		"S1/E1": {Label: graphschema.Epoch, Properties: map[string]any{"id": "E1", "name": "Screening", "nextId": "E2"}},
+		"S1/E2": {Label: graphschema.Epoch, Properties: map[string]any{"id": "E2", "previousId": "E1"}},
+		"S1/C1": {Label: graphschema.Code, Properties: map[string]any{"id": "C1", "code": "C48262", "codeSystem": "http://www.cdisc.org"}},
+		"S1/C2": {Label: graphschema.Code, Properties: map[string]any{"id": "C2", "code": "C10001", "codeSystem": "MedDRA"}},
+	}
+	edges := []graphschema.StoredEdge{
+		{From: "S1/E1", Label: graphschema.Precedes, To: "S1/E2"},
+		{From: "S1/E1", Label: graphschema.HasType, To: "S1/C1"},
+		{From: "S1/E2", Label: graphschema.HasType, To: "S1/C2"},
+		// An edge to a node outside the subgraph is left out.
+		{From: "S1/E2", Label: graphschema.HasType, To: "S2/C9"},
+	}
+	return nodes, edges
+}
+
+func TestFromSubgraph(t *testing.T) {
+	nodes, edges := subgraph()
+	g := FromSubgraph("S1", nodes, edges, base)
+
+	study, c1, c2, e1, e2 := base+"S1", base+"S1/Code/C1", base+"S1/Code/C2", base+"S1/Epoch/E1", base+"S1/Epoch/E2"
+	want := []Triple{
+		{study, rdfType, Term{IRI: USDM + "Study"}},
+		{study, USDM + "id", Term{Value: "S1"}},
+		{study, USDM + "label", Term{Value: "a"}},
+		{study, USDM + "label", Term{Value: "b"}},
+		{study, USDM + "name", Term{Value: "Study \"One\"\nline"}},
+		{c1, rdfType, Term{IRI: USDM + "Code"}},
+		{c1, USDM + "code", Term{IRI: NCIt + "C48262"}},
+		{c1, USDM + "codeSystem", Term{Value: "http://www.cdisc.org"}},
+		{c1, USDM + "id", Term{Value: "C1"}},
+		{c2, rdfType, Term{IRI: USDM + "Code"}},
+		{c2, USDM + "code", Term{Value: "C10001"}},
+		{c2, USDM + "codeSystem", Term{Value: "MedDRA"}},
+		{c2, USDM + "id", Term{Value: "C2"}},
+		{e1, rdfType, Term{IRI: USDM + "Epoch"}},
+		{e1, USDM + "id", Term{Value: "E1"}},
+		{e1, USDM + "name", Term{Value: "Screening"}},
+		{e1, USDM + "nextId", Term{IRI: e2}},
+		{e1, USDM + "type", Term{IRI: c1}},
+		{e2, rdfType, Term{IRI: USDM + "Epoch"}},
+		{e2, USDM + "id", Term{Value: "E2"}},
+		{e2, USDM + "previousId", Term{IRI: e1}},
+		{e2, USDM + "type", Term{IRI: c2}},
+	}
+	if !slices.Equal(g.Triples, want) {
+		t.Errorf("FromSubgraph =\n%v\nwant\n%v", g.Triples, want)
+	}
+}
+
+func TestNCIConcept(t *testing.T) {
+	tests := []struct {
+		code, system string
+		want         string
+	}{
+		{"C48262", "http://www.cdisc.org", NCIt + "C48262"},
+		{"C48262", "CDISC CT", NCIt + "C48262"},
+		{"C48262", "NCI", NCIt + "C48262"},
+		{"C48262", "NCI Thesaurus", NCIt + "C48262"},
+		{"C48262", "MedDRA", ""},
+		{"10001", "NCIt", ""},
+		{"C48262", "", ""},
+	}
+	for _, tt := range tests {
+		if got := nciConcept(map[string]any{"code": tt.code, "codeSystem": tt.system}); got != tt.want {
+			t.Errorf("nciConcept(%q, %q) = %q, want %q", tt.code, tt.system, got, tt.want)
+		}
+	}
+}
+
+func TestTurtle(t *testing.T) {
+	nodes, edges := subgraph()
+	got := FromSubgraph("S1", nodes, edges, base).Turtle()
+
+	want := `@prefix usdm: <http://www.cdisc.org/USDM#> .
+@prefix ncit: <http://ncicb.nci.nih.gov/xml/owl/EVS/Thesaurus.owl#> .
+@prefix rdf: <http://www.w3.org/1999/02/22-rdf-syntax-ns#> .
+@prefix xsd: <http://www.w3.org/2001/XMLSchema#> .
+
+<http://example.org/S1>
+    a usdm:Study ;
+    usdm:id "S1" ;
+    usdm:label "a", "b" ;
+    usdm:name "Study \"One\"\nline" .
+
+<http://example.org/S1/Code/C1>
+    a usdm:Code ;
+    usdm:code ncit:C48262 ;
+    usdm:codeSystem "http://www.cdisc.org" ;
+    usdm:id "C1" .
+
+<http://example.org/S1/Code/C2>
+    a usdm:Code ;
+    usdm:code "C10001" ;
+    usdm:codeSystem "MedDRA" ;
+    usdm:id "C2" .
+
+<http://example.org/S1/Epoch/E1>
+    a usdm:Epoch ;
+    usdm:id "E1" ;
+    usdm:name "Screening" ;
+    usdm:nextId <http://example.org/S1/Epoch/E2> ;
+    usdm:type <http://example.org/S1/Code/C1> .
+
+<http://example.org/S1/Epoch/E2>
+    a usdm:Epoch ;
+    usdm:id "E2" ;
+    usdm:previousId <http://example.org/S1/Epoch/E1> ;
+    usdm:type <http://example.org/S1/Code/C2> .
+`
+	if got != want {
+		t.Errorf("Turtle =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTurtleTerms(t *testing.T) {
+	tests := []struct {
+		term Term
+		want string
+	}{
+		{Term{Value: "tab\tquote\" back\\ cr\r"}, `"tab\tquote\" back\\ cr\r"`},
+		{Term{Value: true}, "true"},
+		{Term{Value: 42}, "42"},
+		{Term{Value: 1.5}, `"1.5E+00"^^xsd:double`},
+		{Term{IRI: USDM + "Study"}, "usdm:Study"},
+		// Not a local name, so written in full.
+		{Term{IRI: USDM + "a b"}, `<http://www.cdisc.org/USDM#a\u0020b>`},
+		{Term{IRI: `http://example.org/<a>{b}|"c"`}, `<http://example.org/\u003Ca\u003E\u007Bb\u007D\u007C\u0022c\u0022>`},
+	}
+	for _, tt := range tests {
+		if got := turtleTerm(tt.term); got != tt.want {
+			t.Errorf("turtleTerm(%+v) = %s, want %s", tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestResourceIRIEscapesIDs(t *testing.T) {
+	node := graphschema.StoredNode{Label: graphschema.Epoch, Properties: map[string]any{"id": "E 1/2"}}
+	if got, want := resourceIRI(base, "S 1", graphschema.USDM, node), base+"S%201/Epoch/E%201%2F2"; got != want {
+		t.Errorf("resourceIRI = %s, want %s", got, want)
+	}
+}
+
+func TestJSONLD(t *testing.T) {
+	nodes, edges := subgraph()
+	doc := FromSubgraph("S1", nodes, edges, base).JSONLD()
+
+	// Compared as the JSON it is served as.
+	raw, err := json.Marshal(doc["@graph"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	var want []map[string]any
+	if err := json.Unmarshal([]byte(`[
+		{"@id":"http://example.org/S1","@type":"usdm:Study","usdm:id":"S1","usdm:label":["a","b"],"usdm:name":"Study \"One\"\nline"},
+		{"@id":"http://example.org/S1/Code/C1","@type":"usdm:Code","usdm:code":{"@id":"ncit:C48262"},
+			"usdm:codeSystem":"http://www.cdisc.org","usdm:id":"C1"},
+		{"@id":"http://example.org/S1/Code/C2","@type":"usdm:Code","usdm:code":"C10001","usdm:codeSystem":"MedDRA","usdm:id":"C2"},
+		{"@id":"http://example.org/S1/Epoch/E1","@type":"usdm:Epoch","usdm:id":"E1","usdm:name":"Screening",
+			"usdm:nextId":{"@id":"http://example.org/S1/Epoch/E2"},"usdm:type":{"@id":"http://example.org/S1/Code/C1"}},
+		{"@id":"http://example.org/S1/Epoch/E2","@type":"usdm:Epoch","usdm:id":"E2",
+			"usdm:previousId":{"@id":"http://example.org/S1/Epoch/E1"},"usdm:type":{"@id":"http://example.org/S1/Code/C2"}}
+	]`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("@graph =\n%v\nwant\n%v", got, want)
+	}
+	if ctx := doc["@context"].(map[string]any); ctx["usdm"] != USDM || ctx["ncit"] != NCIt {
+		t.Errorf("@context = %v", ctx)
+	}
+}
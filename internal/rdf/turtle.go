@@ -0,0 +1,115 @@
+package rdf
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Turtle writes g as a Turtle document, a block of statements per resource.
+func (g Graph) Turtle() string {
+	var b strings.Builder
+	for _, p := range prefixes {
+		fmt.Fprintf(&b, "@prefix %s: <%s> .\n", p.prefix, p.namespace)
+	}
+
+	for i, t := range g.Triples {
+		switch {
+		case i == 0 || g.Triples[i-1].Subject != t.Subject:
+			fmt.Fprintf(&b, "\n<%s>\n    %s %s", escapeIRI(t.Subject), predicateName(t.Predicate), turtleTerm(t.Object))
+		case g.Triples[i-1].Predicate != t.Predicate:
+			fmt.Fprintf(&b, " ;\n    %s %s", predicateName(t.Predicate), turtleTerm(t.Object))
+		default:
+			fmt.Fprintf(&b, ", %s", turtleTerm(t.Object))
+		}
+		if i == len(g.Triples)-1 || g.Triples[i+1].Subject != t.Subject {
+			b.WriteString(" .\n")
+		}
+	}
+	return b.String()
+}
+
+func predicateName(iri string) string {
+	if iri == rdfType {
+		return "a"
+	}
+	return turtleIRI(iri)
+}
+
+func turtleTerm(t Term) string {
+	if t.IRI != "" {
+		return turtleIRI(t.IRI)
+	}
+	switch v := t.Value.(type) {
+	case string:
+		return quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v)
+	case float32:
+		return double(float64(v))
+	case float64:
+		return double(v)
+	default:
+		return quote(fmt.Sprint(v))
+	}
+}
+
+func double(f float64) string {
+	return quote(doubleLexical(f)) + "^^xsd:double"
+}
+
+// doubleLexical writes f in the lexical space of xsd:double.
+func doubleLexical(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "INF"
+	case math.IsInf(f, -1):
+		return "-INF"
+	default:
+		return strconv.FormatFloat(f, 'E', -1, 64)
+	}
+}
+
+// localName matches the local names written as prefixed names; any other
+// IRI in a known namespace is written in full.
+var localName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// turtleIRI writes iri as a prefixed name if it is in one of the prefixed
+// namespaces.
+func turtleIRI(iri string) string {
+	if name := compactIRI(iri); name != iri {
+		return name
+	}
+	return "<" + escapeIRI(iri) + ">"
+}
+
+// escapeIRI escapes the characters an IRI reference cannot hold.
+func escapeIRI(iri string) string {
+	var b strings.Builder
+	for _, r := range iri {
+		if r <= 0x20 || strings.ContainsRune(`<>"{}|^`+"`\\", r) {
+			fmt.Fprintf(&b, `\u%04X`, r)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var literalEscapes = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+func quote(s string) string {
+	return `"` + literalEscapes.Replace(s) + `"`
+}
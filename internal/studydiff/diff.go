@@ -0,0 +1,206 @@
+// Package studydiff compares two study versions read back from the graph
+// (see graphread), entity by entity, for reviewers asking what changed
+// between two versions or two submissions of a study.
+package studydiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// Section is a group of entities compared together, found under the given
+// field paths of a study version document.
+type Section struct {
+	Name  string
+	Paths [][]string
+}
+
+// Sections are compared in this order.
+var Sections = []Section{
+	{Name: "arms", Paths: [][]string{{"studyDesigns", "arms"}}},
+	{Name: "epochs", Paths: [][]string{{"studyDesigns", "epochs"}}},
+	{Name: "encounters", Paths: [][]string{{"studyDesigns", "encounters"}}},
+	{Name: "activities", Paths: [][]string{{"studyDesigns", "activities"}}},
+	{Name: "procedures", Paths: [][]string{{"studyDesigns", "activities", "definedProcedures"}}},
+	{Name: "eligibility", Paths: [][]string{{"studyDesigns", "eligibilityCriteria"}, {"eligibilityCriterionItems"}}},
+	{Name: "interventions", Paths: [][]string{{"studyInterventions"}}},
+}
+
+// Compare diffs two study version documents. leftName and rightName say
+// which versions they are in the result.
+func Compare(leftName string, left map[string]any, rightName string, right map[string]any) *models.StudyVersionDiff {
+	diff := &models.StudyVersionDiff{Left: leftName, Right: rightName, Sections: []*models.SectionDiff{}}
+	for _, section := range Sections {
+		diff.Sections = append(diff.Sections, compareSection(section, left, right))
+	}
+	return diff
+}
+
+func compareSection(section Section, left, right map[string]any) *models.SectionDiff {
+	sd := &models.SectionDiff{
+		Section: section.Name,
+		Added:   []*models.DiffEntity{},
+		Removed: []*models.DiffEntity{},
+		Changed: []*models.EntityChange{},
+	}
+
+	before := entities(left, section.Paths)
+	after := entities(right, section.Paths)
+
+	for _, id := range sortedKeys(after) {
+		old, ok := before[id]
+		if !ok {
+			sd.Added = append(sd.Added, summarize(id, after[id]))
+			continue
+		}
+		if changes := compareProperties(flatten(old), flatten(after[id])); len(changes) > 0 {
+			sd.Changed = append(sd.Changed, &models.EntityChange{
+				DiffEntity: *summarize(id, after[id]),
+				Properties: changes,
+			})
+		}
+	}
+	for _, id := range sortedKeys(before) {
+		if _, ok := after[id]; !ok {
+			sd.Removed = append(sd.Removed, summarize(id, before[id]))
+		}
+	}
+	return sd
+}
+
+// entities collects the entities under paths by id. An entity listed more
+// than once, such as an activity shared by two designs, is kept once.
+func entities(version map[string]any, paths [][]string) map[string]map[string]any {
+	found := make(map[string]map[string]any)
+	var walk func(doc map[string]any, path []string)
+	walk = func(doc map[string]any, path []string) {
+		for _, item := range asList(doc[path[0]]) {
+			child, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			if len(path) > 1 {
+				walk(child, path[1:])
+				continue
+			}
+			if id, ok := child["id"].(string); ok {
+				found[id] = child
+			}
+		}
+	}
+	for _, path := range paths {
+		walk(version, path)
+	}
+	return found
+}
+
+func asList(v any) []any {
+	switch v := v.(type) {
+	case []any:
+		return v
+	case map[string]any:
+		return []any{v}
+	default:
+		return nil
+	}
+}
+
+func summarize(id string, entity map[string]any) *models.DiffEntity {
+	e := &models.DiffEntity{ID: id}
+	if name, ok := entity["name"].(string); ok {
+		e.Name = &name
+	}
+	e.InstanceType, _ = entity["instanceType"].(string)
+	return e
+}
+
+// flatten renders an entity's properties as strings keyed by path. Nested
+// objects, such as codes, contribute their properties under a dotted path;
+// their ids are left out, being generated per document rather than
+// meaningful. Lists of objects are child collections, compared as sections
+// of their own or not at all.
+func flatten(entity map[string]any) map[string]string {
+	flat := make(map[string]string)
+	var walk func(prefix string, doc map[string]any)
+	walk = func(prefix string, doc map[string]any) {
+		for key, value := range doc {
+			if prefix != "" && key == "id" {
+				continue
+			}
+			path := prefix + key
+			switch v := value.(type) {
+			case nil:
+			case map[string]any:
+				walk(path+".", v)
+			case []any:
+				if len(v) > 0 {
+					if _, isObject := v[0].(map[string]any); isObject {
+						continue
+					}
+				}
+				flat[path] = render(v)
+			default:
+				flat[path] = render(v)
+			}
+		}
+	}
+	walk("", entity)
+	return flat
+}
+
+func render(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
+		}
+		sort.Strings(parts)
+		b, _ := json.Marshal(parts)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func compareProperties(before, after map[string]string) []*models.PropertyChange {
+	keys := make(map[string]bool, len(before)+len(after))
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+
+	var changes []*models.PropertyChange
+	for _, k := range sortedKeys(keys) {
+		b, hadBefore := before[k]
+		a, hasAfter := after[k]
+		if hadBefore == hasAfter && a == b {
+			continue
+		}
+		change := &models.PropertyChange{Property: k}
+		if hadBefore {
+			change.Before = &b
+		}
+		if hasAfter {
+			change.After = &a
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,139 @@
+package studydiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+func document(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", s, err)
+	}
+	return doc
+}
+
+// lines renders a section diff as "+id", "-id" and "~id property: before
+// -> after", with <nil> for an absent value.
+func lines(sd *models.SectionDiff) []string {
+	value := func(s *string) string {
+		if s == nil {
+			return "<nil>"
+		}
+		return *s
+	}
+	var out []string
+	for _, e := range sd.Added {
+		out = append(out, "+"+e.ID)
+	}
+	for _, e := range sd.Removed {
+		out = append(out, "-"+e.ID)
+	}
+	for _, c := range sd.Changed {
+		for _, p := range c.Properties {
+			out = append(out, fmt.Sprintf("~%s %s: %s -> %s", c.ID, p.Property, value(p.Before), value(p.After)))
+		}
+	}
+	return out
+}
+
+func TestCompare(t *testing.T) {
+	left := document(t, `{"studyDesigns": [{
+		"id": "D1",
+		"arms": [
+			{"id": "A1", "name": "Placebo", "instanceType": "StudyArm"},
+			{"id": "A2", "name": "Drug", "instanceType": "StudyArm"}
+		],
+		"epochs": [{"id": "E1", "name": "Screening", "type": {"id": "C1", "code": "C48262", "decode": "Screening"}}],
+		"activities": [{"id": "ACT1", "name": "Consent", "definedProcedures": [{"id": "P1", "name": "Sign"}]}]
+	}]}`)
+	right := document(t, `{"studyDesigns": [{
+		"id": "D1",
+		"arms": [
+			{"id": "A1", "name": "Placebo", "instanceType": "StudyArm"},
+			{"id": "A3", "name": "Drug 10mg", "instanceType": "StudyArm"}
+		],
+		"epochs": [{"id": "E1", "name": "Screening period", "type": {"id": "C9", "code": "C48262", "decode": "Screening"}}],
+		"activities": [{"id": "ACT1", "name": "Consent", "definedProcedures": [{"id": "P1", "name": "Sign form"}]}]
+	}]}`)
+
+	diff := Compare("1", left, "2", right)
+	if diff.Left != "1" || diff.Right != "2" {
+		t.Errorf("Left, Right = %q, %q, want 1, 2", diff.Left, diff.Right)
+	}
+	if len(diff.Sections) != len(Sections) {
+		t.Fatalf("got %d sections, want %d", len(diff.Sections), len(Sections))
+	}
+
+	want := map[string][]string{
+		"arms":   {"+A3", "-A2"},
+		"epochs": {"~E1 name: Screening -> Screening period"},
+		// The procedure changed, not the activity listing it.
+		"procedures": {"~P1 name: Sign -> Sign form"},
+	}
+	for i, sd := range diff.Sections {
+		if sd.Section != Sections[i].Name {
+			t.Errorf("section %d = %s, want %s", i, sd.Section, Sections[i].Name)
+		}
+		if got := lines(sd); !slices.Equal(got, want[sd.Section]) {
+			t.Errorf("%s: got %q, want %q", sd.Section, got, want[sd.Section])
+		}
+	}
+
+	added := diff.Sections[0].Added[0]
+	if added.Name == nil || *added.Name != "Drug 10mg" || added.InstanceType != "StudyArm" {
+		t.Errorf("added arm = %+v, want Drug 10mg, StudyArm", added)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity string
+		want   map[string]string
+	}{
+		{
+			name:   "scalars",
+			entity: `{"id": "A1", "name": "Placebo", "order": 2, "blinded": true, "label": null}`,
+			want:   map[string]string{"id": "A1", "name": "Placebo", "order": "2", "blinded": "true"},
+		},
+		{
+			name:   "nested object ids skipped",
+			entity: `{"id": "E1", "type": {"id": "C1", "code": "C48262", "standardCode": {"id": "C2", "code": "X"}}}`,
+			want:   map[string]string{"id": "E1", "type.code": "C48262", "type.standardCode.code": "X"},
+		},
+		{
+			name:   "object lists skipped",
+			entity: `{"id": "ACT1", "definedProcedures": [{"id": "P1"}], "biomedicalConceptIds": []}`,
+			want:   map[string]string{"id": "ACT1", "biomedicalConceptIds": "[]"},
+		},
+		{
+			name:   "scalar lists sorted",
+			entity: `{"id": "ACT1", "biomedicalConceptIds": ["BC2", "BC10", "BC1"]}`,
+			want:   map[string]string{"id": "ACT1", "biomedicalConceptIds": `["BC1","BC10","BC2"]`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flatten(document(t, tt.entity)); !maps.Equal(got, tt.want) {
+				t.Errorf("flatten = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareIgnoresReorderedLists(t *testing.T) {
+	left := document(t, `{"studyDesigns": [{"activities": [{"id": "ACT1", "biomedicalConceptIds": ["BC1", "BC2"]}]}]}`)
+	right := document(t, `{"studyDesigns": [{"activities": [{"id": "ACT1", "biomedicalConceptIds": ["BC2", "BC1"]}]}]}`)
+	for _, sd := range Compare("1", left, "2", right).Sections {
+		if got := lines(sd); len(got) > 0 {
+			t.Errorf("%s: got %q for a reordered list", sd.Section, got)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package studyevent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+const (
+	// TypeAttribute and StudyIDAttribute are the SNS message attributes
+	// holding an event's Type and study id.
+	TypeAttribute    = "eventType"
+	StudyIDAttribute = "studyId"
+
+	// maxMessageSize is the largest message SNS takes, leaving room for
+	// the attributes.
+	maxMessageSize = 250 * 1024
+)
+
+// SNSPublisher is the Publisher backed by the study events topic.
+type SNSPublisher struct {
+	client   *sns.Client
+	topicARN string
+}
+
+func NewSNSPublisher(cfg aws.Config, topicARN string) *SNSPublisher {
+	return &SNSPublisher{client: sns.NewFromConfig(cfg), topicARN: topicARN}
+}
+
+func (p *SNSPublisher) Publish(ctx context.Context, event Event) error {
+	message, err := encode(event)
+	if err != nil {
+		return err
+	}
+	_, err = p.client.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(p.topicARN),
+		Message:  aws.String(string(message)),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			TypeAttribute: {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(string(event.Type)),
+			},
+			StudyIDAttribute: {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(event.StudyID),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish %s event of study %s: %w", event.Type, event.StudyID, err)
+	}
+	return nil
+}
+
+// encode marshals event, without its changes if they make it too large.
+func encode(event Event) ([]byte, error) {
+	message, err := json.Marshal(event)
+	if err == nil && len(message) > maxMessageSize {
+		event.Changes = nil
+		event.ChangesOmitted = true
+		message, err = json.Marshal(event)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", event.Type, err)
+	}
+	return message, nil
+}
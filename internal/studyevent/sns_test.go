@@ -0,0 +1,66 @@
+package studyevent
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// changesOf returns a summary of n added arms with long ids.
+func changesOf(n int) models.ChangeSummary {
+	added := make([]string, n)
+	for i := range added {
+		added[i] = fmt.Sprintf("StudyArm_%0100d", i)
+	}
+	return models.ChangeSummary{"Arm": {Added: added}}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name        string
+		changes     models.ChangeSummary
+		wantOmitted bool
+	}{
+		{"no changes", nil, false},
+		{"small changes", changesOf(10), false},
+		{"changes just fitting", changesOf(2000), false},
+		{"changes too large", changesOf(5000), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := Ingested(models.Study{ID: "S1"}, "sub-1", 2, tt.changes)
+			message, err := encode(event)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if len(message) > maxMessageSize {
+				t.Errorf("message is %d bytes, over %d", len(message), maxMessageSize)
+			}
+
+			var decoded Event
+			if err := json.Unmarshal(message, &decoded); err != nil {
+				t.Fatalf("failed to unmarshal message: %v", err)
+			}
+			if decoded.ChangesOmitted != tt.wantOmitted {
+				t.Errorf("ChangesOmitted = %t, want %t", decoded.ChangesOmitted, tt.wantOmitted)
+			}
+			if tt.wantOmitted && decoded.Changes != nil {
+				t.Error("omitted changes are still in the message")
+			}
+			if !tt.wantOmitted && len(decoded.Changes) != len(tt.changes) {
+				t.Errorf("changes = %v, want them kept", decoded.Changes)
+			}
+			if decoded.StudyID != "S1" || decoded.Type != StudyUpdated || decoded.Revision != 2 {
+				t.Errorf("decoded event = %+v", decoded)
+			}
+		})
+	}
+
+	// The event encode was given keeps its changes.
+	event := Ingested(models.Study{ID: "S1"}, "sub-1", 2, changesOf(5000))
+	if _, err := encode(event); err != nil || event.Changes == nil || event.ChangesOmitted {
+		t.Error("encode changed the event it was given")
+	}
+}
@@ -0,0 +1,98 @@
+// Package studyevent tells downstream systems what happened to a study.
+// sdrProcessor publishes StudyIngested or StudyUpdated once a submission's
+// write has committed, and the resolver StudyDeleted once deleteStudy has
+// removed a study. Events are JSON Event documents on the study events
+// SNS topic, with their Type and study id also as message attributes for
+// subscription filter policies.
+package studyevent
+
+import (
+	"context"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// TopicEnv is the environment variable holding the study events topic ARN.
+const TopicEnv = "STUDY_EVENTS_TOPIC_ARN"
+
+// SchemaVersion is the version of the Event document. It changes whenever
+// a field is removed or changes meaning; consumers should ignore fields
+// they do not know.
+const SchemaVersion = 1
+
+type Type string
+
+const (
+	// StudyIngested: the first revision of a study was written.
+	StudyIngested Type = "StudyIngested"
+	// StudyUpdated: a later submission of the study was written.
+	StudyUpdated Type = "StudyUpdated"
+	// StudyDeleted: the study and its revisions were removed.
+	StudyDeleted Type = "StudyDeleted"
+)
+
+// Event is one thing that happened to a study. Revision, SubmissionID and
+// Changes are set for StudyIngested and StudyUpdated only. Changes is left
+// out, with ChangesOmitted set, when it would not fit in a message; the
+// submission's status still has it.
+type Event struct {
+	Type           Type                 `json:"type"`
+	SchemaVersion  int                  `json:"schemaVersion"`
+	StudyID        string               `json:"studyId"`
+	VersionIDs     []string             `json:"versionIds"`
+	SubmissionID   string               `json:"submissionId,omitempty"`
+	Revision       int                  `json:"revision,omitempty"`
+	Changes        models.ChangeSummary `json:"changes,omitempty"`
+	ChangesOmitted bool                 `json:"changesOmitted,omitempty"`
+	Time           time.Time            `json:"time"`
+}
+
+// Publisher sends events to whoever subscribed to them.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Ingested returns the event for revision of study having been written by
+// a submission: StudyIngested for the first revision, StudyUpdated after.
+func Ingested(study models.Study, submissionID string, revision int, changes models.ChangeSummary) Event {
+	t := StudyUpdated
+	if revision == 1 {
+		t = StudyIngested
+	}
+	return Event{
+		Type:          t,
+		SchemaVersion: SchemaVersion,
+		StudyID:       study.ID,
+		VersionIDs:    versionIDs(study),
+		SubmissionID:  submissionID,
+		Revision:      revision,
+		Changes:       changes,
+		Time:          time.Now().UTC(),
+	}
+}
+
+// Deleted returns the event for the study studyID, which had the versions
+// versionIDs, having been deleted.
+func Deleted(studyID string, versionIDs []string) Event {
+	if versionIDs == nil {
+		versionIDs = []string{}
+	}
+	return Event{
+		Type:          StudyDeleted,
+		SchemaVersion: SchemaVersion,
+		StudyID:       studyID,
+		VersionIDs:    versionIDs,
+		Time:          time.Now().UTC(),
+	}
+}
+
+func versionIDs(study models.Study) []string {
+	ids := []string{}
+	for _, v := range study.Versions {
+		if v != nil {
+			ids = append(ids, v.ID)
+		}
+	}
+	return ids
+}
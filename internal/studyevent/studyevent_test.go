@@ -0,0 +1,57 @@
+package studyevent
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+func TestIngested(t *testing.T) {
+	study := models.Study{ID: "S1", Versions: []*models.StudyVersion{{ID: "V1"}, nil, {ID: "V2"}}}
+	changes := models.ChangeSummary{"Arm": {Added: []string{"A1"}}}
+
+	tests := []struct {
+		revision int
+		want     Type
+	}{
+		{1, StudyIngested},
+		{2, StudyUpdated},
+		{17, StudyUpdated},
+	}
+	for _, tt := range tests {
+		event := Ingested(study, "sub-1", tt.revision, changes)
+		if event.Type != tt.want {
+			t.Errorf("revision %d: Type = %s, want %s", tt.revision, event.Type, tt.want)
+		}
+		if event.StudyID != "S1" || event.SubmissionID != "sub-1" || event.Revision != tt.revision || event.SchemaVersion != SchemaVersion {
+			t.Errorf("revision %d: event = %+v", tt.revision, event)
+		}
+		if !slices.Equal(event.VersionIDs, []string{"V1", "V2"}) {
+			t.Errorf("revision %d: VersionIDs = %v, want V1, V2", tt.revision, event.VersionIDs)
+		}
+		if event.Time.IsZero() || event.Time.Location().String() != "UTC" {
+			t.Errorf("revision %d: Time = %v, want now in UTC", tt.revision, event.Time)
+		}
+	}
+}
+
+func TestDeleted(t *testing.T) {
+	tests := []struct {
+		versionIDs []string
+		want       []string
+	}{
+		{[]string{"V1"}, []string{"V1"}},
+		// A study deleted without versions still lists them, as [].
+		{nil, []string{}},
+	}
+	for _, tt := range tests {
+		event := Deleted("S1", tt.versionIDs)
+		if event.Type != StudyDeleted || event.StudyID != "S1" || event.Revision != 0 || event.Changes != nil {
+			t.Errorf("Deleted = %+v", event)
+		}
+		if event.VersionIDs == nil || !slices.Equal(event.VersionIDs, tt.want) {
+			t.Errorf("VersionIDs = %#v, want %#v", event.VersionIDs, tt.want)
+		}
+	}
+}
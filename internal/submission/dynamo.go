@@ -0,0 +1,167 @@
+package submission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TableEnv is the environment variable holding the submission table name.
+const TableEnv = "SUBMISSION_TABLE"
+
+// DynamoStore keeps one item per submission in a DynamoDB table keyed by
+// "id".
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+}
+
+func NewDynamoStore(cfg aws.Config, table string) *DynamoStore {
+	return &DynamoStore{
+		client: dynamodb.NewFromConfig(cfg),
+		table:  table,
+	}
+}
+
+func (d *DynamoStore) Create(ctx context.Context, record Record) error {
+	now := time.Now().UTC()
+	if record.Status == "" {
+		record.Status = Queued
+	}
+	if record.SubmittedAt.IsZero() {
+		record.SubmittedAt = now
+	}
+	record.UpdatedAt = now
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal submission %s: %w", record.ID, err)
+	}
+
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(d.table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create submission %s: %w", record.ID, err)
+	}
+	return nil
+}
+
+func (d *DynamoStore) Get(ctx context.Context, id string) (*Record, error) {
+	out, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.table),
+		Key:            key(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission %s: %w", id, err)
+	}
+	if out.Item == nil {
+		return nil, ErrNotFound
+	}
+
+	var record Record
+	if err := attributevalue.UnmarshalMap(out.Item, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal submission %s: %w", id, err)
+	}
+	return &record, nil
+}
+
+func (d *DynamoStore) MarkProcessing(ctx context.Context, id string) error {
+	return d.update(ctx, id,
+		"SET #status = :status, #updatedAt = :now ADD #attempts :one REMOVE #error",
+		map[string]types.AttributeValue{
+			":status": &types.AttributeValueMemberS{Value: string(Processing)},
+			":one":    &types.AttributeValueMemberN{Value: "1"},
+		})
+}
+
+func (d *DynamoStore) MarkSucceeded(ctx context.Context, id string, revision int, changes models.ChangeSummary) error {
+	summary, err := attributevalue.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal changes of submission %s: %w", id, err)
+	}
+	return d.update(ctx, id,
+		"SET #status = :status, #updatedAt = :now, #revision = :revision, #changes = :changes REMOVE #error",
+		map[string]types.AttributeValue{
+			":status":   &types.AttributeValueMemberS{Value: string(Succeeded)},
+			":revision": &types.AttributeValueMemberN{Value: strconv.Itoa(revision)},
+			":changes":  summary,
+		})
+}
+
+func (d *DynamoStore) MarkFailed(ctx context.Context, id string, cause error) error {
+	return d.update(ctx, id,
+		"SET #status = :status, #updatedAt = :now, #error = :error",
+		map[string]types.AttributeValue{
+			":status": &types.AttributeValueMemberS{Value: string(Failed)},
+			":error":  &types.AttributeValueMemberS{Value: cause.Error()},
+		})
+}
+
+func (d *DynamoStore) MarkRejected(ctx context.Context, id string, cause error) error {
+	return d.update(ctx, id,
+		"SET #status = :status, #updatedAt = :now, #error = :error",
+		map[string]types.AttributeValue{
+			":status": &types.AttributeValueMemberS{Value: string(Rejected)},
+			":error":  &types.AttributeValueMemberS{Value: cause.Error()},
+		})
+}
+
+func (d *DynamoStore) update(ctx context.Context, id, expression string, values map[string]types.AttributeValue) error {
+	names := map[string]string{
+		"#status":    "status",
+		"#updatedAt": "updatedAt",
+		"#error":     "error",
+		"#attempts":  "attempts",
+		"#revision":  "revision",
+		"#changes":   "changes",
+	}
+	// DynamoDB rejects names that the expression does not use.
+	for placeholder := range names {
+		if !strings.Contains(expression, placeholder) {
+			delete(names, placeholder)
+		}
+	}
+
+	now, err := attributevalue.Marshal(time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to marshal timestamp: %w", err)
+	}
+	values[":now"] = now
+
+	_, err = d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(d.table),
+		Key:                       key(id),
+		UpdateExpression:          aws.String(expression),
+		ConditionExpression:       aws.String("attribute_exists(id)"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update submission %s: %w", id, err)
+	}
+	return nil
+}
+
+func key(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"id": &types.AttributeValueMemberS{Value: id},
+	}
+}
@@ -0,0 +1,100 @@
+package submission
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// MemoryStore is a Store backed by a map rather than DynamoDB, so the
+// submission lifecycle can be exercised without a table.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]Record),
+		now:     time.Now,
+	}
+}
+
+func (m *MemoryStore) Create(_ context.Context, record Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.records[record.ID]; ok {
+		return fmt.Errorf("submission %s already exists", record.ID)
+	}
+	now := m.now().UTC()
+	if record.Status == "" {
+		record.Status = Queued
+	}
+	if record.SubmittedAt.IsZero() {
+		record.SubmittedAt = now
+	}
+	record.UpdatedAt = now
+	m.records[record.ID] = record
+	return nil
+}
+
+func (m *MemoryStore) Get(_ context.Context, id string) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &record, nil
+}
+
+func (m *MemoryStore) MarkProcessing(_ context.Context, id string) error {
+	return m.update(id, func(r *Record) {
+		r.Status = Processing
+		r.Error = ""
+		r.Attempts++
+	})
+}
+
+func (m *MemoryStore) MarkSucceeded(_ context.Context, id string, revision int, changes models.ChangeSummary) error {
+	return m.update(id, func(r *Record) {
+		r.Status = Succeeded
+		r.Error = ""
+		r.Revision = revision
+		r.Changes = changes
+	})
+}
+
+func (m *MemoryStore) MarkFailed(_ context.Context, id string, cause error) error {
+	return m.update(id, func(r *Record) {
+		r.Status = Failed
+		r.Error = cause.Error()
+	})
+}
+
+func (m *MemoryStore) MarkRejected(_ context.Context, id string, cause error) error {
+	return m.update(id, func(r *Record) {
+		r.Status = Rejected
+		r.Error = cause.Error()
+	})
+}
+
+func (m *MemoryStore) update(id string, apply func(*Record)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.records[id]
+	if !ok {
+		return ErrNotFound
+	}
+	apply(&record)
+	record.UpdatedAt = m.now().UTC()
+	m.records[id] = record
+	return nil
+}
@@ -0,0 +1,130 @@
+package submission
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// clock returns a MemoryStore whose clock advances a minute per reading.
+func clock() (*MemoryStore, time.Time) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemoryStore()
+	next := start
+	m.now = func() time.Time {
+		t := next
+		next = next.Add(time.Minute)
+		return t
+	}
+	return m, start
+}
+
+func TestMemoryStoreLifecycle(t *testing.T) {
+	ctx := context.Background()
+	cause := errors.New("graph unavailable")
+	changes := models.ChangeSummary{"Arm": {Added: []string{"A1"}}}
+
+	tests := []struct {
+		name   string
+		finish func(*MemoryStore, string) error
+		want   Record
+	}{
+		{
+			name: "succeeded",
+			finish: func(m *MemoryStore, id string) error {
+				return m.MarkSucceeded(ctx, id, 2, changes)
+			},
+			want: Record{Status: Succeeded, Attempts: 1, Revision: 2, Changes: changes},
+		},
+		{
+			name: "failed",
+			finish: func(m *MemoryStore, id string) error {
+				return m.MarkFailed(ctx, id, cause)
+			},
+			want: Record{Status: Failed, Attempts: 1, Error: cause.Error()},
+		},
+		{
+			name: "rejected",
+			finish: func(m *MemoryStore, id string) error {
+				return m.MarkRejected(ctx, id, cause)
+			},
+			want: Record{Status: Rejected, Attempts: 1, Error: cause.Error()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, start := clock()
+			if err := m.Create(ctx, Record{ID: "sub-1", StudyID: "S1"}); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			queued, err := m.Get(ctx, "sub-1")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if queued.Status != Queued || !queued.SubmittedAt.Equal(start) || !queued.UpdatedAt.Equal(start) {
+				t.Errorf("created record = %+v, want queued at %v", queued, start)
+			}
+
+			if err := m.MarkProcessing(ctx, "sub-1"); err != nil {
+				t.Fatalf("MarkProcessing: %v", err)
+			}
+			if r, _ := m.Get(ctx, "sub-1"); r.Status != Processing || r.Attempts != 1 {
+				t.Errorf("after MarkProcessing status = %s, attempts = %d, want processing, 1", r.Status, r.Attempts)
+			}
+
+			if err := tt.finish(m, "sub-1"); err != nil {
+				t.Fatalf("finish: %v", err)
+			}
+			got, _ := m.Get(ctx, "sub-1")
+			want := tt.want
+			want.ID, want.StudyID = "sub-1", "S1"
+			want.SubmittedAt, want.UpdatedAt = start, start.Add(2*time.Minute)
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("record = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreRetryClearsError(t *testing.T) {
+	ctx := context.Background()
+	m, _ := clock()
+	if err := m.Create(ctx, Record{ID: "sub-1"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	m.MarkProcessing(ctx, "sub-1")
+	m.MarkFailed(ctx, "sub-1", errors.New("timeout"))
+	m.MarkProcessing(ctx, "sub-1")
+
+	got, _ := m.Get(ctx, "sub-1")
+	if got.Status != Processing || got.Error != "" {
+		t.Errorf("record = %+v, want processing with no error", *got)
+	}
+
+	m.MarkSucceeded(ctx, "sub-1", 1, models.ChangeSummary{})
+	got, _ = m.Get(ctx, "sub-1")
+	if got.Status != Succeeded || got.Error != "" || got.Attempts != 2 {
+		t.Errorf("record = %+v, want succeeded on attempt 2 with no error", *got)
+	}
+}
+
+func TestMemoryStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	if err := m.Create(ctx, Record{ID: "sub-1"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := m.Create(ctx, Record{ID: "sub-1"}); err == nil {
+		t.Error("Create of an existing id succeeded")
+	}
+	if _, err := m.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+	if err := m.MarkProcessing(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("MarkProcessing(missing) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,81 @@
+// Package submission tracks an SDR from the moment sdrHandler accepts it to
+// the moment sdrProcessor has written it to the graph, or given up on it.
+package submission
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// MessageAttribute is the SQS message attribute that carries the submission
+// id from sdrHandler to sdrProcessor.
+const MessageAttribute = "SubmissionId"
+
+// SubmittedAtAttribute is the SQS message attribute that carries when the
+// submission was accepted, in RFC 3339. sdrProcessor does not write a study
+// over what a later submission of it wrote.
+const SubmittedAtAttribute = "SubmittedAt"
+
+// StudyIDAttribute is the SQS message attribute that carries the id of the
+// submitted study, so sdrProcessor can tell which messages of a batch are
+// of the same study before reading payloads sent by reference.
+const StudyIDAttribute = "StudyId"
+
+type Status string
+
+const (
+	Queued     Status = "queued"
+	Processing Status = "processing"
+	Succeeded  Status = "succeeded"
+	Failed     Status = "failed"
+	// Rejected submissions were not written because the graph already
+	// holds a newer version of the study; Error says which.
+	Rejected Status = "rejected"
+)
+
+// ErrNotFound is returned by Store.Get when no submission has the id.
+var ErrNotFound = errors.New("submission not found")
+
+// Record is the lifecycle of one submission as exposed by GET /sdr/{id}.
+// Error holds the reason for the latest failure and is cleared on success.
+// Revision is the study revision a successful write created and Changes
+// summarizes what it added, updated and removed.
+type Record struct {
+	ID          string               `json:"id" dynamodbav:"id"`
+	StudyID     string               `json:"studyId,omitempty" dynamodbav:"studyId,omitempty"`
+	UsdmVersion string               `json:"usdmVersion,omitempty" dynamodbav:"usdmVersion,omitempty"`
+	Status      Status               `json:"status" dynamodbav:"status"`
+	Error       string               `json:"error,omitempty" dynamodbav:"error,omitempty"`
+	Attempts    int                  `json:"attempts" dynamodbav:"attempts"`
+	Revision    int                  `json:"revision,omitempty" dynamodbav:"revision,omitempty"`
+	Changes     models.ChangeSummary `json:"changes,omitempty" dynamodbav:"changes,omitempty"`
+	SubmittedAt time.Time            `json:"submittedAt" dynamodbav:"submittedAt"`
+	UpdatedAt   time.Time            `json:"updatedAt" dynamodbav:"updatedAt"`
+}
+
+// Store persists submission records. Create is called once by sdrHandler;
+// the processor moves a record along with the remaining methods.
+type Store interface {
+	Create(ctx context.Context, record Record) error
+	Get(ctx context.Context, id string) (*Record, error)
+	MarkProcessing(ctx context.Context, id string) error
+	MarkSucceeded(ctx context.Context, id string, revision int, changes models.ChangeSummary) error
+	MarkFailed(ctx context.Context, id string, cause error) error
+	MarkRejected(ctx context.Context, id string, cause error) error
+}
+
+// NewID returns a random RFC 4122 version 4 UUID.
+func NewID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate submission id: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,135 @@
+package usdm
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// Adapter rewrites a study document as submitted under one USDM version
+// into the canonical shape described by pkg/models. It works on the
+// decoded JSON so that it can rename and move properties the canonical
+// model has no field for.
+type Adapter interface {
+	Normalize(study map[string]any) error
+}
+
+// AdapterFunc lets a plain function serve as an Adapter.
+type AdapterFunc func(study map[string]any) error
+
+func (f AdapterFunc) Normalize(study map[string]any) error {
+	return f(study)
+}
+
+// UnsupportedVersionError is returned for a usdmVersion no adapter is
+// registered for.
+type UnsupportedVersionError struct {
+	Version string
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("unsupported usdmVersion %q, supported versions are %s",
+		e.Version, strings.Join(SupportedVersions(), ", "))
+}
+
+var (
+	adaptersMu sync.RWMutex
+	adapters   = map[string]Adapter{}
+)
+
+// Register adds the adapter for a USDM version. The version is either an
+// exact major.minor such as "3.0" or a major-wide fallback such as "3.x",
+// which serves every minor version without an exact adapter.
+func Register(version string, adapter Adapter) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+
+	if _, dup := adapters[version]; dup {
+		panic(fmt.Sprintf("usdm: adapter for version %s registered twice", version))
+	}
+	adapters[version] = adapter
+}
+
+// Lookup returns the adapter for a declared usdmVersion such as "3.0.0".
+func Lookup(declared string) (Adapter, bool) {
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+
+	minor := MinorVersion(declared)
+	if a, ok := adapters[minor]; ok {
+		return a, true
+	}
+	major, _, found := strings.Cut(minor, ".")
+	if !found {
+		return nil, false
+	}
+	a, ok := adapters[major+".x"]
+	return a, ok
+}
+
+// SupportedVersions lists the registered versions in order.
+func SupportedVersions() []string {
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+
+	versions := make([]string, 0, len(adapters))
+	for v := range adapters {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Decode normalizes a study document submitted under usdmVersion and
+// decodes it into the canonical model. Nothing is returned for a version
+// without an adapter, so an unknown shape is never partially ingested.
+func Decode(usdmVersion string, study json.RawMessage) (models.Study, error) {
+	adapter, ok := Lookup(usdmVersion)
+	if !ok {
+		return models.Study{}, &UnsupportedVersionError{Version: usdmVersion}
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(study, &doc); err != nil {
+		return models.Study{}, fmt.Errorf("failed to parse study: %w", err)
+	}
+	if doc == nil {
+		return models.Study{}, fmt.Errorf("study is missing")
+	}
+
+	if err := adapter.Normalize(doc); err != nil {
+		return models.Study{}, fmt.Errorf("failed to normalize usdmVersion %s study: %w", usdmVersion, err)
+	}
+
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		return models.Study{}, fmt.Errorf("failed to marshal normalized study: %w", err)
+	}
+
+	var s models.Study
+	if err := json.Unmarshal(canonical, &s); err != nil {
+		return models.Study{}, fmt.Errorf("failed to decode normalized study: %w", err)
+	}
+	return s, nil
+}
+
+// versions returns the study versions of a study document.
+func versions(study map[string]any) []map[string]any {
+	return objects(study["versions"])
+}
+
+// objects returns the JSON objects in a list value, skipping anything else.
+func objects(v any) []map[string]any {
+	list, _ := v.([]any)
+	out := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		if obj, ok := item.(map[string]any); ok {
+			out = append(out, obj)
+		}
+	}
+	return out
+}
@@ -0,0 +1,27 @@
+package usdm
+
+import "github.com/ankit-lilly/dtd-go-backend/pkg/models"
+
+// ExportVersion is the usdmVersion an exported study declares. Exports are
+// in the canonical shape, which the 3.0 adapter takes as it is.
+const ExportVersion = "3.0.0"
+
+// ExportSystemName is the systemName an exported study declares.
+const ExportSystemName = "dtd-go-backend"
+
+// Payload is an SDR payload as POST /sdr takes it.
+type Payload struct {
+	Study         models.Study `json:"study"`
+	UsdmVersion   string       `json:"usdmVersion"`
+	SystemName    string       `json:"systemName,omitempty"`
+	SystemVersion string       `json:"systemVersion,omitempty"`
+}
+
+// Export returns the payload that submits study again.
+func Export(study models.Study) Payload {
+	return Payload{
+		Study:       study,
+		UsdmVersion: ExportVersion,
+		SystemName:  ExportSystemName,
+	}
+}
@@ -0,0 +1,218 @@
+package usdm_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/internal/usdm"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+const submitted = `{
+	"usdmVersion": "3.0.0",
+	"systemName": "test",
+	"study": {
+		"id": "S1",
+		"name": "Round trip",
+		"versions": [{
+			"id": "V1",
+			"versionIdentifier": "2",
+			"studyDesigns": [{
+				"id": "D1",
+				"name": "Main design",
+				"arms": [{"id": "A1", "name": "Placebo"}, {"id": "A2", "name": "Drug"}],
+				"epochs": [
+					{"id": "E1", "name": "Screening", "nextId": "E2"},
+					{"id": "E2", "name": "Treatment", "previousId": "E1"}
+				],
+				"studyCells": [
+					{"id": "C1", "armId": "A1", "epochId": "E1"},
+					{"id": "C2", "armId": "A2", "epochId": "E2"}
+				],
+				"encounters": [{"id": "N1", "name": "Visit 1"}],
+				"activities": [{"id": "ACT1", "name": "Consent"}, {"id": "ACT2", "name": "Dosing"}],
+				"scheduleTimelines": [{
+					"id": "T1",
+					"name": "Main timeline",
+					"entryId": "I1",
+					"instances": [
+						{"id": "I1", "encounterId": "N1", "epochId": "E1", "activityIds": ["ACT1", "ACT2"]}
+					]
+				}]
+			}]
+		}]
+	}
+}`
+
+// stored returns the nodes and edges the writer leaves in the graph for
+// doc: a node per entity, an edge per child and an edge per id reference
+// that resolves within the study.
+func stored(doc map[string]any) (map[string]graphschema.StoredNode, []graphschema.StoredEdge) {
+	schema := graphschema.USDM
+	studyID, _ := doc["id"].(string)
+	nodes := make(map[string]graphschema.StoredNode)
+	var edges []graphschema.StoredEdge
+
+	for key, e := range schema.Entities(doc) {
+		properties := map[string]any{"id": e.ID, graphschema.KeyProperty: key, graphschema.StudyProperty: studyID}
+		for p, v := range e.Properties {
+			if v != nil {
+				properties[p] = v
+			}
+		}
+		nodes[key] = graphschema.StoredNode{Label: e.Label, Properties: properties}
+	}
+
+	var walk func(label string, doc map[string]any)
+	walk = func(label string, doc map[string]any) {
+		id, _ := doc["id"].(string)
+		key := schema.Key(label, studyID, id)
+		for _, e := range schema.Children(label) {
+			var children []any
+			switch child := doc[e.Field].(type) {
+			case map[string]any:
+				children = []any{child}
+			case []any:
+				children = child
+			}
+			for _, c := range children {
+				if m, ok := c.(map[string]any); ok {
+					childID, _ := m["id"].(string)
+					edges = append(edges, graphschema.StoredEdge{From: key, Label: e.Label, To: schema.Key(e.To, studyID, childID)})
+					walk(e.To, m)
+				}
+			}
+		}
+		for _, r := range schema.References {
+			if r.From != label {
+				continue
+			}
+			var ids []any
+			switch v := doc[r.Property].(type) {
+			case string:
+				ids = []any{v}
+			case []any:
+				ids = v
+			}
+			for _, target := range ids {
+				targetID, _ := target.(string)
+				to := schema.Key(r.To, studyID, targetID)
+				if nodes[to].Label != r.To {
+					continue
+				}
+				if r.Inverse {
+					edges = append(edges, graphschema.StoredEdge{From: to, Label: r.Label, To: key})
+				} else {
+					edges = append(edges, graphschema.StoredEdge{From: key, Label: r.Label, To: to})
+				}
+			}
+		}
+	}
+	walk(schema.Root, doc)
+	return nodes, edges
+}
+
+// TestExportRoundTrip writes a payload's study to a graph, reads it back
+// the way the exporter does and checks the export is a valid payload that
+// writes the same entities again.
+func TestExportRoundTrip(t *testing.T) {
+	if violations, err := usdm.Validate([]byte(submitted)); err != nil || len(violations) > 0 {
+		t.Fatalf("the submitted payload is not valid: %v %v", violations, err)
+	}
+	// The processor writes the study as decoded into the models.
+	var payload struct {
+		Study json.RawMessage `json:"study"`
+	}
+	if err := json.Unmarshal([]byte(submitted), &payload); err != nil {
+		t.Fatalf("failed to unmarshal payload: %v", err)
+	}
+	decoded, err := usdm.Decode("3.0.0", payload.Study)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	written := studyMap(t, decoded)
+
+	nodes, edges := stored(written)
+	if !edgeSet(edges)[graphschema.StoredEdge{From: "S1/C1", Label: graphschema.InArm, To: "S1/A1"}] {
+		t.Fatalf("the stored graph lacks the cell's arm reference: %v", edges)
+	}
+	doc := graphschema.USDM.Document("S1", nodes, edges)
+	if doc == nil {
+		t.Fatal("Document found no study")
+	}
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("failed to marshal document: %v", err)
+	}
+	var study models.Study
+	if err := json.Unmarshal(encoded, &study); err != nil {
+		t.Fatalf("failed to decode document: %v", err)
+	}
+
+	exported, err := json.Marshal(usdm.Export(study))
+	if err != nil {
+		t.Fatalf("failed to marshal export: %v", err)
+	}
+	violations, err := usdm.Validate(exported)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for _, v := range violations {
+		t.Errorf("export violates the schema at %s: %s", v.Pointer, v.Message)
+	}
+
+	var again struct {
+		UsdmVersion string         `json:"usdmVersion"`
+		Study       map[string]any `json:"study"`
+	}
+	if err := json.Unmarshal(exported, &again); err != nil {
+		t.Fatalf("failed to unmarshal export: %v", err)
+	}
+	if again.UsdmVersion != usdm.ExportVersion {
+		t.Errorf("usdmVersion = %q, want %q", again.UsdmVersion, usdm.ExportVersion)
+	}
+	before, after := graphschema.USDM.Entities(written), graphschema.USDM.Entities(again.Study)
+	if !reflect.DeepEqual(keys(before), keys(after)) {
+		t.Errorf("export writes entities %v, want %v", keys(after), keys(before))
+	}
+	againNodes, againEdges := stored(again.Study)
+	if !reflect.DeepEqual(edgeSet(againEdges), edgeSet(edges)) {
+		t.Errorf("export writes edges %v, want %v", edgeSet(againEdges), edgeSet(edges))
+	}
+	for key, n := range nodes {
+		if !reflect.DeepEqual(againNodes[key], n) {
+			t.Errorf("node %s: export writes %v, want %v", key, againNodes[key], n)
+		}
+	}
+}
+
+func studyMap(t *testing.T, study models.Study) map[string]any {
+	t.Helper()
+	encoded, err := json.Marshal(study)
+	if err != nil {
+		t.Fatalf("failed to marshal study: %v", err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(encoded, &m); err != nil {
+		t.Fatalf("failed to unmarshal study: %v", err)
+	}
+	return m
+}
+
+func keys(entities map[string]graphschema.Entity) map[string]string {
+	labels := make(map[string]string, len(entities))
+	for key, e := range entities {
+		labels[key] = e.Label
+	}
+	return labels
+}
+
+func edgeSet(edges []graphschema.StoredEdge) map[graphschema.StoredEdge]bool {
+	set := make(map[graphschema.StoredEdge]bool, len(edges))
+	for _, e := range edges {
+		set[e] = true
+	}
+	return set
+}
@@ -0,0 +1,41 @@
+package usdm
+
+// ChainOrder orders items along their previousId/nextId links. Each chain
+// starts at an item whose previous item is absent; items the links do not
+// reach, e.g. because of a cycle, keep their relative order at the end.
+func ChainOrder[T any](items []*T, links func(*T) (id string, previous, next *string)) []*T {
+	byID := make(map[string]*T, len(items))
+	for _, item := range items {
+		id, _, _ := links(item)
+		byID[id] = item
+	}
+
+	ordered := make([]*T, 0, len(items))
+	placed := make(map[string]bool, len(items))
+
+	for _, item := range items {
+		id, previous, _ := links(item)
+		if previous != nil && byID[*previous] != nil {
+			continue
+		}
+		for current := item; current != nil && !placed[id]; {
+			placed[id] = true
+			ordered = append(ordered, current)
+
+			_, _, next := links(current)
+			if next == nil {
+				break
+			}
+			current = byID[*next]
+			id = *next
+		}
+	}
+
+	for _, item := range items {
+		if id, _, _ := links(item); !placed[id] {
+			placed[id] = true
+			ordered = append(ordered, item)
+		}
+	}
+	return ordered
+}
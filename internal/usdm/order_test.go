@@ -0,0 +1,78 @@
+package usdm
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+type link struct {
+	id, previous, next string
+}
+
+func (l *link) links() (string, *string, *string) {
+	ptr := func(s string) *string {
+		if s == "" {
+			return nil
+		}
+		return &s
+	}
+	return l.id, ptr(l.previous), ptr(l.next)
+}
+
+func TestChainOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []link
+		want  string
+	}{
+		{
+			name:  "one chain",
+			items: []link{{"C", "B", ""}, {"A", "", "B"}, {"B", "A", "C"}},
+			want:  "A B C",
+		},
+		{
+			name:  "chains in the order their first items are listed",
+			items: []link{{"Y", "X", ""}, {"B", "A", ""}, {"X", "", "Y"}, {"A", "", "B"}},
+			want:  "X Y A B",
+		},
+		{
+			name:  "cycle at the end",
+			items: []link{{"A", "B", "B"}, {"C", "", ""}, {"B", "A", "A"}},
+			want:  "C A B",
+		},
+		{
+			name:  "dangling nextId",
+			items: []link{{"A", "", "Z"}, {"B", "Z", ""}},
+			want:  "A B",
+		},
+		{
+			name:  "dangling previousId starts a chain",
+			items: []link{{"B", "A", "C"}, {"C", "B", ""}},
+			want:  "B C",
+		},
+		{
+			name:  "unlinked",
+			items: []link{{"B", "", ""}, {"A", "", ""}},
+			want:  "B A",
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := make([]*link, len(tt.items))
+			for i := range tt.items {
+				items[i] = &tt.items[i]
+			}
+			var got []string
+			for _, item := range ChainOrder(items, (*link).links) {
+				got = append(got, item.id)
+			}
+			if want := strings.Fields(tt.want); !slices.Equal(got, want) {
+				t.Errorf("ChainOrder = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package usdm
+
+import "fmt"
+
+func init() {
+	Register("3.0", AdapterFunc(normalizeV3_0))
+}
+
+// normalizeV3_0 accepts the forms USDM 3.0 exporters still produce: a
+// single documentedBy object instead of a list, and study identifiers
+// written as studyIdentifier with the scope organization embedded in
+// studyIdentifierScope rather than as text and scopeId.
+func normalizeV3_0(study map[string]any) error {
+	if doc, ok := study["documentedBy"].(map[string]any); ok {
+		study["documentedBy"] = []any{doc}
+	}
+
+	for _, version := range versions(study) {
+		for _, identifier := range objects(version["studyIdentifiers"]) {
+			if text, ok := identifier["studyIdentifier"]; ok {
+				if _, has := identifier["text"]; !has {
+					identifier["text"] = text
+				}
+				delete(identifier, "studyIdentifier")
+			}
+
+			scope, ok := identifier["studyIdentifierScope"].(map[string]any)
+			if !ok {
+				continue
+			}
+			delete(identifier, "studyIdentifierScope")
+
+			scopeID, _ := scope["id"].(string)
+			if scopeID == "" {
+				return fmt.Errorf("study identifier %v has a scope organization without an id", identifier["id"])
+			}
+			identifier["scopeId"] = scopeID
+			addOrganization(version, scope)
+		}
+	}
+	return nil
+}
+
+// addOrganization adds org to the version's organizations unless an
+// organization with the same id is already listed.
+func addOrganization(version map[string]any, org map[string]any) {
+	for _, existing := range objects(version["organizations"]) {
+		if existing["id"] == org["id"] {
+			return
+		}
+	}
+	list, _ := version["organizations"].([]any)
+	version["organizations"] = append(list, org)
+}
@@ -0,0 +1,35 @@
+package usdm
+
+func init() {
+	Register("3.x", AdapterFunc(normalizeV3_x))
+}
+
+// normalizeV3_x handles USDM 3 releases after 3.0, which describe the
+// study phase and study type once on the study version. The canonical
+// model keeps them on each study design, so they are copied down to every
+// design that does not set its own. Anything normalizeV3_0 accepts is
+// accepted here too.
+func normalizeV3_x(study map[string]any) error {
+	if err := normalizeV3_0(study); err != nil {
+		return err
+	}
+
+	for _, version := range versions(study) {
+		for _, property := range []string{"studyPhase", "studyType"} {
+			value, ok := version[property]
+			if !ok {
+				continue
+			}
+			delete(version, property)
+			if value == nil {
+				continue
+			}
+			for _, design := range objects(version["studyDesigns"]) {
+				if design[property] == nil {
+					design[property] = value
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+// Package usdm holds what the SDR pipeline knows about the CDISC Unified
+// Study Definitions Model itself, independent of how it is stored.
+package usdm
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// canonicalSchema describes a payload after its version adapter has run,
+// i.e. the shape of pkg/models.
+const canonicalSchema = "schemas/usdm-3.0.json"
+
+// referenceProperties are id-valued properties that must point at an entity
+// defined elsewhere in the same document.
+var referenceProperties = map[string]bool{
+	"previousId":                      true,
+	"nextId":                          true,
+	"scheduledAtId":                   true,
+	"timelineId":                      true,
+	"armId":                           true,
+	"epochId":                         true,
+	"elementIds":                      true,
+	"criterionItemId":                 true,
+	"entryId":                         true,
+	"activityIds":                     true,
+	"encounterId":                     true,
+	"timelineExitId":                  true,
+	"defaultConditionId":              true,
+	"relativeFromScheduledInstanceId": true,
+	"relativeToScheduledInstanceId":   true,
+}
+
+// Violation is one reason a document was rejected. Pointer is the RFC 6901
+// JSON pointer of the offending value within the submitted document.
+type Violation struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+var (
+	compileOnce sync.Once
+	compiled    *jsonschema.Schema
+	compileErr  error
+	printer     = message.NewPrinter(language.English)
+)
+
+// Validate normalizes a submitted SDR payload with the adapter for its
+// declared usdmVersion, checks the result against the USDM JSON schema and
+// checks that id references resolve within the document. It returns every
+// violation found, pointing into the document as submitted; an error means
+// the document could not be checked at all.
+func Validate(document []byte) ([]Violation, error) {
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(document))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	root, ok := inst.(map[string]any)
+	if !ok {
+		return []Violation{{Pointer: "", Message: "document must be a JSON object"}}, nil
+	}
+
+	declared, _ := root["usdmVersion"].(string)
+	if declared == "" {
+		return []Violation{{Pointer: "/usdmVersion", Message: "usdmVersion is required"}}, nil
+	}
+	adapter, ok := Lookup(declared)
+	if !ok {
+		err := &UnsupportedVersionError{Version: declared}
+		return []Violation{{Pointer: "/usdmVersion", Message: err.Error()}}, nil
+	}
+
+	// Adapters neither add nor change ids, so references are checked
+	// before the document is normalized.
+	violations := referenceViolations(root)
+
+	origin := recordOrigins(root)
+	if study, ok := root["study"].(map[string]any); ok {
+		if err := adapter.Normalize(study); err != nil {
+			return []Violation{{Pointer: "/study", Message: err.Error()}}, nil
+		}
+	}
+
+	schema, err := canonical()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := schema.Validate(inst); err != nil {
+		var ve *jsonschema.ValidationError
+		if !errors.As(err, &ve) {
+			return nil, fmt.Errorf("failed to validate document: %w", err)
+		}
+		for _, v := range schemaViolations(ve) {
+			v.Pointer = pointer(origin.submitted(root, v.location))
+			violations = append(violations, v.Violation)
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Pointer < violations[j].Pointer
+	})
+	// A value an adapter copied to several places is reported once.
+	return slices.Compact(violations), nil
+}
+
+// MinorVersion reduces a declared usdmVersion such as "3.0.0" to "3.0".
+func MinorVersion(declared string) string {
+	parts := strings.SplitN(strings.TrimPrefix(strings.TrimSpace(declared), "v"), ".", 3)
+	if len(parts) < 2 {
+		return strings.Join(parts, ".")
+	}
+	return parts[0] + "." + parts[1]
+}
+
+func canonical() (*jsonschema.Schema, error) {
+	compileOnce.Do(func() {
+		compiled, compileErr = compile(canonicalSchema)
+	})
+	return compiled, compileErr
+}
+
+func compile(file string) (*jsonschema.Schema, error) {
+	raw, err := schemaFiles.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema %s: %w", file, err)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema %s: %w", file, err)
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(file, doc); err != nil {
+		return nil, fmt.Errorf("failed to load schema %s: %w", file, err)
+	}
+	s, err := c.Compile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema %s: %w", file, err)
+	}
+	return s, nil
+}
+
+// schemaViolation is a violation found in the normalized document, at the
+// location given by its pointer tokens.
+type schemaViolation struct {
+	Violation
+	location []string
+}
+
+// schemaViolations flattens the validation error tree into its leaves,
+// which are the errors that name a concrete instance location.
+func schemaViolations(ve *jsonschema.ValidationError) []schemaViolation {
+	if len(ve.Causes) == 0 {
+		return []schemaViolation{{
+			Violation: Violation{Message: ve.ErrorKind.LocalizedString(printer)},
+			location:  ve.InstanceLocation,
+		}}
+	}
+
+	var violations []schemaViolation
+	for _, cause := range ve.Causes {
+		violations = append(violations, schemaViolations(cause)...)
+	}
+	return violations
+}
+
+// origins records where each object of a submitted document was, and
+// what it held, before its adapter ran. Adapters rename and move
+// properties but keep the objects themselves, so an object is found again
+// by identity wherever it was moved to.
+type origins map[uintptr]origin
+
+type origin struct {
+	path       []string
+	properties map[string]any
+}
+
+func recordOrigins(root map[string]any) origins {
+	o := origins{}
+	walk(root, nil, func(obj map[string]any, path []string) {
+		o[reflect.ValueOf(obj).Pointer()] = origin{path: path, properties: maps.Clone(obj)}
+	})
+	return o
+}
+
+// submitted maps a location in the normalized document back to the
+// submitted one. The nearest enclosing object is located by identity. A
+// property below it that was renamed maps to the submitted property that
+// held the same value; one that was made up by the adapter maps to the
+// object itself.
+func (o origins) submitted(root map[string]any, location []string) []string {
+	var (
+		node    any = root
+		nearest     = o[reflect.ValueOf(root).Pointer()]
+		obj         = root
+		rest        = location
+	)
+	for i, token := range location {
+		switch n := node.(type) {
+		case map[string]any:
+			node = n[token]
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(n) {
+				node = nil
+			} else {
+				node = n[index]
+			}
+		default:
+			node = nil
+		}
+		if m, ok := node.(map[string]any); ok {
+			if at, ok := o[reflect.ValueOf(m).Pointer()]; ok {
+				nearest, obj, rest = at, m, location[i+1:]
+			}
+		}
+	}
+
+	path := slices.Clone(nearest.path)
+	if len(rest) == 0 {
+		return path
+	}
+	if _, ok := nearest.properties[rest[0]]; ok {
+		return append(path, rest...)
+	}
+	value := obj[rest[0]]
+	for prop, was := range nearest.properties {
+		if _, kept := obj[prop]; !kept && reflect.DeepEqual(was, value) {
+			return append(append(path, prop), rest[1:]...)
+		}
+	}
+	return path
+}
+
+// referenceViolations reports every reference property whose id is not
+// defined anywhere in the document.
+func referenceViolations(root map[string]any) []Violation {
+	ids := map[string]bool{}
+	walk(root, nil, func(obj map[string]any, _ []string) {
+		if id, ok := obj["id"].(string); ok && id != "" {
+			ids[id] = true
+		}
+	})
+
+	var violations []Violation
+	walk(root, nil, func(obj map[string]any, path []string) {
+		for prop, value := range obj {
+			if !referenceProperties[prop] {
+				continue
+			}
+			propPath := append(path[:len(path):len(path)], prop)
+			switch v := value.(type) {
+			case string:
+				if v != "" && !ids[v] {
+					violations = append(violations, Violation{
+						Pointer: pointer(propPath),
+						Message: fmt.Sprintf("%s %q does not reference an entity in this document", prop, v),
+					})
+				}
+			case []any:
+				for i, item := range v {
+					if ref, ok := item.(string); ok && ref != "" && !ids[ref] {
+						violations = append(violations, Violation{
+							Pointer: pointer(append(propPath, fmt.Sprint(i))),
+							Message: fmt.Sprintf("%s entry %q does not reference an entity in this document", prop, ref),
+						})
+					}
+				}
+			}
+		}
+	})
+	return violations
+}
+
+func walk(v any, path []string, visit func(obj map[string]any, path []string)) {
+	switch node := v.(type) {
+	case map[string]any:
+		visit(node, path)
+		for key, child := range node {
+			walk(child, append(path[:len(path):len(path)], key), visit)
+		}
+	case []any:
+		for i, child := range node {
+			walk(child, append(path[:len(path):len(path)], fmt.Sprint(i)), visit)
+		}
+	}
+}
+
+func pointer(tokens []string) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString("/")
+		b.WriteString(escaper.Replace(t))
+	}
+	return b.String()
+}
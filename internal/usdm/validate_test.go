@@ -0,0 +1,159 @@
+package usdm
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     []Violation
+	}{
+		{
+			name:     "valid",
+			document: `{"usdmVersion":"3.0.0","study":{"id":"S1","versions":[{"id":"V1","versionIdentifier":"1"}]}}`,
+		},
+		{
+			name:     "missing usdmVersion",
+			document: `{"study":{"id":"S1"}}`,
+			want:     []Violation{{Pointer: "/usdmVersion", Message: "usdmVersion is required"}},
+		},
+		{
+			name:     "wrong type",
+			document: `{"usdmVersion":"3.0.0","study":{"id":5}}`,
+			want:     []Violation{{Pointer: "/study/id", Message: "got number, want string"}},
+		},
+		{
+			name:     "missing required property",
+			document: `{"usdmVersion":"3.0.0","study":{"id":"S1","versions":[{"id":"V1"}]}}`,
+			want:     []Violation{{Pointer: "/study/versions/0", Message: "missing property 'versionIdentifier'"}},
+		},
+		{
+			name: "dangling reference",
+			document: `{"usdmVersion":"3.0.0","study":{"id":"S1","versions":[{"id":"V1","versionIdentifier":"1",
+				"studyDesigns":[{"id":"D1","epochs":[{"id":"E1","nextId":"E9"}]}]}]}}`,
+			want: []Violation{{
+				Pointer: "/study/versions/0/studyDesigns/0/epochs/0/nextId",
+				Message: `nextId "E9" does not reference an entity in this document`,
+			}},
+		},
+		{
+			name: "dangling reference in a list",
+			document: `{"usdmVersion":"3.0.0","study":{"id":"S1","versions":[{"id":"V1","versionIdentifier":"1",
+				"studyDesigns":[{"id":"D1","activities":[{"id":"A1"}],"scheduleTimelines":[{"id":"T1",
+				"instances":[{"id":"I1","activityIds":["A1","A2"]}]}]}]}]}}`,
+			want: []Violation{{
+				Pointer: "/study/versions/0/studyDesigns/0/scheduleTimelines/0/instances/0/activityIds/1",
+				Message: `activityIds entry "A2" does not reference an entity in this document`,
+			}},
+		},
+		{
+			name: "single documentedBy under 3.0",
+			document: `{"usdmVersion":"3.0.0","study":{"id":"S1","versions":[{"id":"V1","versionIdentifier":"1"}],
+				"documentedBy":{"id":"D1","name":5}}}`,
+			want: []Violation{{Pointer: "/study/documentedBy/name", Message: "got number, want null or string"}},
+		},
+		{
+			name: "documentedBy without an id under 3.0",
+			document: `{"usdmVersion":"3.0.0","study":{"id":"S1","versions":[{"id":"V1","versionIdentifier":"1"}],
+				"documentedBy":{"name":"Protocol"}}}`,
+			want: []Violation{{Pointer: "/study/documentedBy", Message: "missing property 'id'"}},
+		},
+		{
+			name: "embedded scope organization under 3.0",
+			document: `{"usdmVersion":"3.0.0","study":{"id":"S1","versions":[{"id":"V1","versionIdentifier":"1",
+				"studyIdentifiers":[{"id":"SI1","studyIdentifier":"NCT1","studyIdentifierScope":{"id":"O1","name":5}}]}]}}`,
+			want: []Violation{{
+				Pointer: "/study/versions/0/studyIdentifiers/0/studyIdentifierScope/name",
+				Message: "got number, want null or string",
+			}},
+		},
+		{
+			name: "study phase copied to every design under 3.1",
+			document: `{"usdmVersion":"3.1.0","study":{"id":"S1","versions":[{"id":"V1","versionIdentifier":"1",
+				"studyPhase":{"id":"P1","standardCode":5,"instanceType":"AliasCode"},
+				"studyDesigns":[{"id":"D1"},{"id":"D2"}]}]}}`,
+			want: []Violation{{Pointer: "/study/versions/0/studyPhase/standardCode", Message: "got number, want null or object"}},
+		},
+		{
+			name:     "escaped pointer",
+			document: `{"usdmVersion":"3.0.0","study":{"id":"S1","a/b~c":{"id":"X","armId":"Arm9"}}}`,
+			want: []Violation{{
+				Pointer: "/study/a~1b~0c/armId",
+				Message: `armId "Arm9" does not reference an entity in this document`,
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Validate([]byte(tt.document))
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Validate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPointer(t *testing.T) {
+	tests := []struct {
+		tokens []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{"study", "versions", "0"}, "/study/versions/0"},
+		{[]string{"a/b"}, "/a~1b"},
+		{[]string{"a~b"}, "/a~0b"},
+		// "~" is escaped first, so the "~1" that "/" becomes is not
+		// escaped again.
+		{[]string{"~/"}, "/~0~1"},
+		{[]string{""}, "/"},
+	}
+	for _, tt := range tests {
+		if got := pointer(tt.tokens); got != tt.want {
+			t.Errorf("pointer(%q) = %q, want %q", tt.tokens, got, tt.want)
+		}
+	}
+}
+
+func TestOriginsSubmitted(t *testing.T) {
+	var root map[string]any
+	if err := json.Unmarshal([]byte(`{"study":{"id":"S1","versions":[{"id":"V1",
+		"studyIdentifiers":[{"id":"SI1","studyIdentifier":"NCT1","studyIdentifierScope":{"id":"O1"}}]}]}}`), &root); err != nil {
+		t.Fatal(err)
+	}
+	origin := recordOrigins(root)
+	if err := normalizeV3_0(root["study"].(map[string]any)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{"", ""},
+		{"/study/id", "/study/id"},
+		// Renamed to text.
+		{"/study/versions/0/studyIdentifiers/0/text", "/study/versions/0/studyIdentifiers/0/studyIdentifier"},
+		// Made up from the embedded organization.
+		{"/study/versions/0/studyIdentifiers/0/scopeId", "/study/versions/0/studyIdentifiers/0"},
+		// Moved to organizations.
+		{"/study/versions/0/organizations/0/id", "/study/versions/0/studyIdentifiers/0/studyIdentifierScope/id"},
+		{"/study/versions/0/organizations", "/study/versions/0"},
+	}
+	for _, tt := range tests {
+		var location []string
+		if tt.location != "" {
+			location = strings.Split(tt.location, "/")[1:]
+		}
+		if got := pointer(origin.submitted(root, location)); got != tt.want {
+			t.Errorf("submitted(%s) = %q, want %q", tt.location, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+module github.com/ankit-lilly/dtd-go-backend/lambdas/exporter
+
+go 1.24.5
+
+require (
+	github.com/ankit-lilly/dtd-go-backend v0.0.0-00010101000000-000000000000
+	github.com/aws/aws-lambda-go v1.49.0
+)
+
+require (
+	github.com/apache/tinkerpop/gremlin-go/v3 v3.7.3 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/gorilla/websocket v1.5.3 // indirect
+	github.com/nicksnyder/go-i18n/v2 v2.4.1 // indirect
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.3 // indirect
+	golang.org/x/text v0.19.0 // indirect
+)
+
+replace github.com/ankit-lilly/dtd-go-backend => ../..
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/fhir"
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphread"
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/internal/neptunedb/gremlin"
+	"github.com/ankit-lilly/dtd-go-backend/internal/rdf"
+	"github.com/ankit-lilly/dtd-go-backend/internal/studydiff"
+	"github.com/ankit-lilly/dtd-go-backend/internal/usdm"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func jsonResponse(statusCode int, body any) events.APIGatewayProxyResponse {
+	b, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("Failed to marshal response body: %v", err)
+		return events.APIGatewayProxyResponse{StatusCode: 500}
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(b),
+	}
+}
+
+// attachment marks a response as a file to save under filename.
+func attachment(response events.APIGatewayProxyResponse, filename string) events.APIGatewayProxyResponse {
+	response.Headers["Content-Disposition"] = fmt.Sprintf("attachment; filename=%q", filename)
+	return response
+}
+
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	log.Printf("Received %s %s", request.HTTPMethod, request.Resource)
+
+	switch request.Resource {
+	case "/sdr/compare":
+		return compare(ctx, request.QueryStringParameters)
+	case "/sdr/{id}/usdm":
+		return exportUSDM(ctx, request.PathParameters["id"], request.QueryStringParameters)
+	case "/sdr/{id}/fhir":
+		return exportFHIR(ctx, request.PathParameters["id"], request.QueryStringParameters)
+	case "/sdr/{id}/rdf":
+		return exportRDF(ctx, request.PathParameters["id"], request.QueryStringParameters)
+	default:
+		return events.APIGatewayProxyResponse{
+			StatusCode: 404,
+			Body:       "Unknown export " + request.Resource,
+		}, nil
+	}
+}
+
+// compare exports the diff between two study versions, named by the same
+// arguments as the compareStudyVersions GraphQL query.
+func compare(ctx context.Context, params map[string]string) (events.APIGatewayProxyResponse, error) {
+	left, err := versionRef(params, "left")
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: 400, Body: err.Error()}, nil
+	}
+	right, err := versionRef(params, "right")
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: 400, Body: err.Error()}, nil
+	}
+
+	g := gremlin.GetReaderGraphTraversalSource()
+
+	leftVersion, err := graphread.StudyVersion(g, left)
+	if err != nil {
+		return versionError(left, err), nil
+	}
+	rightVersion, err := graphread.StudyVersion(g, right)
+	if err != nil {
+		return versionError(right, err), nil
+	}
+
+	diff := studydiff.Compare(left.String(), leftVersion, right.String(), rightVersion)
+	return attachment(jsonResponse(200, diff), fmt.Sprintf("diff-%s-%s.json", left.ID, right.ID)), nil
+}
+
+// exportUSDM exports a study as an SDR payload that submits it again. The
+// versionId and revision parameters pick one version and a revision of the
+// study, as the exportStudy GraphQL query does.
+func exportUSDM(ctx context.Context, studyID string, params map[string]string) (events.APIGatewayProxyResponse, error) {
+	study, failed := storedStudy(studyID, params)
+	if failed != nil {
+		return *failed, nil
+	}
+	return attachment(jsonResponse(200, usdm.Export(study)), fmt.Sprintf("%s.usdm.json", studyID)), nil
+}
+
+// exportFHIR exports a study as a FHIR bundle in the release parameter's
+// FHIR release, R4 unless it says R5. versionId and revision pick the
+// study as they do for exportUSDM.
+func exportFHIR(ctx context.Context, studyID string, params map[string]string) (events.APIGatewayProxyResponse, error) {
+	release, err := fhir.ParseRelease(params["release"])
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: 400, Body: err.Error()}, nil
+	}
+	study, failed := storedStudy(studyID, params)
+	if failed != nil {
+		return *failed, nil
+	}
+	response := jsonResponse(200, fhir.Export(study, release))
+	response.Headers["Content-Type"] = "application/fhir+json"
+	return attachment(response, fmt.Sprintf("%s.fhir-%s.json", studyID, strings.ToLower(string(release)))), nil
+}
+
+// exportRDF exports the subgraph written for a study, or for one of its
+// revisions if revision is set, as Turtle or, with format=jsonld, JSON-LD.
+func exportRDF(ctx context.Context, studyID string, params map[string]string) (events.APIGatewayProxyResponse, error) {
+	format, err := rdf.ParseFormat(params["format"])
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: 400, Body: err.Error()}, nil
+	}
+	revision, failed := revisionParam(params)
+	if failed != nil {
+		return *failed, nil
+	}
+	scope := studyID
+	if revision > 0 {
+		scope = graphschema.SnapshotKey(studyID, revision)
+	}
+
+	nodes, edges, err := graphread.Subgraph(gremlin.GetReaderGraphTraversalSource(), scope)
+	if errors.Is(err, graphread.ErrNotFound) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 404,
+			Body:       fmt.Sprintf("Study %s not found.", describeStudy(studyID, revision, "")),
+		}, nil
+	}
+	if err != nil {
+		log.Printf("Failed to read study %s: %v", describeStudy(studyID, revision, ""), err)
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       "Failed to read study. Please try again later.",
+		}, nil
+	}
+
+	body, err := rdf.FromSubgraph(scope, nodes, edges, rdfBaseIRI).Serialize(format)
+	if err != nil {
+		log.Printf("Failed to serialize study %s: %v", describeStudy(studyID, revision, ""), err)
+		return events.APIGatewayProxyResponse{StatusCode: 500}, nil
+	}
+	return attachment(events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    map[string]string{"Content-Type": format.ContentType()},
+		Body:       string(body),
+	}, fmt.Sprintf("%s.%s", studyID, format.Extension())), nil
+}
+
+// revisionParam reads the revision parameter, 0 if it is not set, or
+// returns the response to fail with.
+func revisionParam(params map[string]string) (int, *events.APIGatewayProxyResponse) {
+	raw, ok := params["revision"]
+	if !ok {
+		return 0, nil
+	}
+	revision, err := strconv.Atoi(raw)
+	if err != nil || revision < 1 {
+		return 0, &events.APIGatewayProxyResponse{StatusCode: 400, Body: "revision must be a number of at least 1"}
+	}
+	return revision, nil
+}
+
+// storedStudy reads the study named by studyID and the versionId and
+// revision parameters, or returns the response to fail with.
+func storedStudy(studyID string, params map[string]string) (models.Study, *events.APIGatewayProxyResponse) {
+	revision, failed := revisionParam(params)
+	if failed != nil {
+		return models.Study{}, failed
+	}
+	versionID := params["versionId"]
+
+	study, err := graphread.StudyModel(gremlin.GetReaderGraphTraversalSource(), studyID, revision, versionID)
+	if errors.Is(err, graphread.ErrNotFound) {
+		return models.Study{}, &events.APIGatewayProxyResponse{
+			StatusCode: 404,
+			Body:       fmt.Sprintf("Study %s not found.", describeStudy(studyID, revision, versionID)),
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to read study %s: %v", describeStudy(studyID, revision, versionID), err)
+		return models.Study{}, &events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       "Failed to read study. Please try again later.",
+		}
+	}
+	return study, nil
+}
+
+// describeStudy names a study, revision and version the way VersionRef
+// does.
+func describeStudy(studyID string, revision int, versionID string) string {
+	if versionID != "" {
+		return graphread.VersionRef{ID: versionID, StudyID: studyID, Revision: revision}.String()
+	}
+	if revision > 0 {
+		return fmt.Sprintf("%s@%d", studyID, revision)
+	}
+	return studyID
+}
+
+func versionRef(params map[string]string, side string) (graphread.VersionRef, error) {
+	ref := graphread.VersionRef{
+		ID:      params[side+"Id"],
+		StudyID: params[side+"StudyId"],
+	}
+	if ref.ID == "" {
+		return ref, fmt.Errorf("%sId is required", side)
+	}
+	if raw, ok := params[side+"Revision"]; ok {
+		revision, err := strconv.Atoi(raw)
+		if err != nil || revision < 1 {
+			return ref, fmt.Errorf("%sRevision must be a number of at least 1", side)
+		}
+		ref.Revision = revision
+	}
+	return ref, nil
+}
+
+func versionError(ref graphread.VersionRef, err error) events.APIGatewayProxyResponse {
+	if errors.Is(err, graphread.ErrNotFound) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 404,
+			Body:       fmt.Sprintf("Study version %s not found.", ref),
+		}
+	}
+	log.Printf("Failed to read study version %s: %v", ref, err)
+	return events.APIGatewayProxyResponse{
+		StatusCode: 500,
+		Body:       "Failed to read study version. Please try again later.",
+	}
+}
+
+// rdfBaseIRI is the IRI RDF exports name study resources under.
+var rdfBaseIRI string
+
+func main() {
+	rdfBaseIRI = os.Getenv(rdf.BaseIRIEnv)
+	lambda.Start(handler)
+}
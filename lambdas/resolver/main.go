@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 
+	"github.com/ankit-lilly/dtd-go-backend/internal/rdf"
+	"github.com/ankit-lilly/dtd-go-backend/internal/studyevent"
 	"github.com/ankit-lilly/dtd-go-backend/lambdas/resolver/mutations"
 	"github.com/ankit-lilly/dtd-go-backend/lambdas/resolver/query"
 
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
 )
 
 type AppSyncEvent struct {
@@ -18,25 +22,45 @@ type AppSyncEvent struct {
 }
 
 type AppSyncInfo struct {
-	FieldName        string   `json:"fieldName"`
-	ParentTypeName   string   `json:"parentTypeName"`
-	SelectionSetList []string `json:"selectionSetList"`
+	FieldName           string         `json:"fieldName"`
+	ParentTypeName      string         `json:"parentTypeName"`
+	SelectionSetList    []string       `json:"selectionSetList"`
+	SelectionSetGraphQL string         `json:"selectionSetGraphQL"`
+	Variables           map[string]any `json:"variables"`
 }
 
 func handler(ctx context.Context, event AppSyncEvent) (interface{}, error) {
 	log.Printf("Received AppSync event: TypeName=%s, FieldName=%s", event.Info.ParentTypeName, event.Info.FieldName)
 
+	fieldArgs := query.ParseFieldArguments(event.Info.SelectionSetGraphQL, event.Info.Variables)
+
 	switch event.Info.ParentTypeName {
 	case "Query":
 		switch event.Info.FieldName {
 		case "study":
-			return query.HandleQueryStudy(ctx, event.Arguments, event.Info.SelectionSetList)
+			return query.HandleQueryStudy(ctx, event.Arguments, event.Info.SelectionSetList, fieldArgs)
+		case "studyRevisions":
+			return query.HandleQueryStudyRevisions(ctx, event.Arguments, event.Info.SelectionSetList, fieldArgs)
+		case "studyVersion":
+			return query.HandleQueryStudyVersion(ctx, event.Arguments, event.Info.SelectionSetList, fieldArgs)
+		case "organization":
+			return query.HandleQueryOrganization(ctx, event.Arguments, event.Info.SelectionSetList, fieldArgs)
 		case "studies":
-			return query.HandleQueryStudies(ctx, event.Arguments, event.Info.SelectionSetList)
+			return query.HandleQueryStudies(ctx, event.Arguments, event.Info.SelectionSetList, fieldArgs)
 		case "activities":
-			return query.HandleQueryActivities(ctx, event.Arguments, event.Info.SelectionSetList)
+			return query.HandleQueryActivities(ctx, event.Arguments, event.Info.SelectionSetList, fieldArgs)
 		case "encounters":
-			return query.HandleQueryEncounters(ctx, event.Arguments, event.Info.SelectionSetList)
+			return query.HandleQueryEncounters(ctx, event.Arguments, event.Info.SelectionSetList, fieldArgs)
+		case "scheduleOfActivities":
+			return query.HandleQueryScheduleOfActivities(ctx, event.Arguments, event.Info.SelectionSetList)
+		case "compareStudyVersions":
+			return query.HandleQueryCompareStudyVersions(ctx, event.Arguments)
+		case "exportStudy":
+			return query.HandleQueryExportStudy(ctx, event.Arguments)
+		case "exportStudyFhir":
+			return query.HandleQueryExportStudyFhir(ctx, event.Arguments)
+		case "exportStudyRdf":
+			return query.HandleQueryExportStudyRdf(ctx, event.Arguments)
 		case "graphStats":
 			return query.HandleQueryGraphStats(ctx, event.Arguments, event.Info.SelectionSetList)
 		default:
@@ -46,6 +70,8 @@ func handler(ctx context.Context, event AppSyncEvent) (interface{}, error) {
 		switch event.Info.FieldName {
 		case "deleteStudy":
 			return mutations.HandleMutationDeleteStudy(ctx, event.Arguments)
+		case "publishStudyIngestion", "publishSubmissionStatus":
+			return mutations.HandleMutationPublish(ctx, event.Arguments)
 		default:
 			return nil, fmt.Errorf("unknown mutation field: %s", event.Info.FieldName)
 		}
@@ -55,5 +81,14 @@ func handler(ctx context.Context, event AppSyncEvent) (interface{}, error) {
 }
 
 func main() {
+	if arn := os.Getenv(studyevent.TopicEnv); arn != "" {
+		cfg, err := config.LoadDefaultConfig(context.TODO())
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		mutations.Events = studyevent.NewSNSPublisher(cfg, arn)
+	}
+	query.RDFBaseIRI = os.Getenv(rdf.BaseIRIEnv)
+
 	lambda.Start(handler)
 }
@@ -3,8 +3,22 @@ package mutations
 import (
 	"context"
 	"fmt"
-	"github.com/ankit-lilly/dtd-go-backend/internal/neptunedb/cypher"
 	"log"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/internal/neptunedb/cypher"
+	"github.com/ankit-lilly/dtd-go-backend/internal/studyevent"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Events announces deleted studies, if set; see internal/studyevent.
+var Events studyevent.Publisher
+
+// readQuery and writeQuery run Cypher against Neptune; tests replace them.
+var (
+	readQuery  = cypher.ExecuteReadQuery
+	writeQuery = cypher.ExecuteWriteQuery
 )
 
 func HandleMutationDeleteStudy(ctx context.Context, args map[string]any) (bool, error) {
@@ -13,13 +27,22 @@ func HandleMutationDeleteStudy(ctx context.Context, args map[string]any) (bool,
 		return false, fmt.Errorf("study ID is required for deletion")
 	}
 
-	query := `MATCH (s:Study {id: $id})
-	OPTIONAL MATCH (s)-[*]->(descendant)
-	DETACH DELETE s, descendant`
+	versionIDs, found, err := studyVersionIDs(ctx, studyID)
+	if err != nil {
+		log.Printf("Error reading study %s before deletion: %v", studyID, err)
+		return false, err
+	}
+
+	// Every node written for the study carries its id, and every node of
+	// one of its revisions the id of the study it is a snapshot of, so this
+	// removes the study and its history and nothing that another study
+	// declared with the same document id.
+	query := fmt.Sprintf(`MATCH (n) WHERE n.%s = $id OR n.%s = $id
+	DETACH DELETE n`, graphschema.StudyProperty, graphschema.SnapshotOfProperty)
 
 	params := map[string]any{"id": studyID}
 
-	err := cypher.ExecuteWriteQuery(ctx, query, params)
+	err = writeQuery(ctx, query, params)
 
 	if err != nil {
 		log.Printf("Error deleting study %s: %v", studyID, err)
@@ -27,5 +50,40 @@ func HandleMutationDeleteStudy(ctx context.Context, args map[string]any) (bool,
 	}
 
 	log.Printf("Successfully deleted study %s and its descendants", studyID)
+
+	// Deleting a study that is not there is not an event. The study is
+	// gone by now, so a failure to announce it is only logged.
+	if found && Events != nil {
+		if err := Events.Publish(ctx, studyevent.Deleted(studyID, versionIDs)); err != nil {
+			log.Printf("Failed to publish %s event of study %s: %v", studyevent.StudyDeleted, studyID, err)
+		}
+	}
 	return true, nil
 }
+
+// studyVersionIDs returns the ids of the live versions of the study, and
+// whether the study exists at all. The versions are collected per study,
+// so a study that is not there returns no row rather than an empty list.
+func studyVersionIDs(ctx context.Context, studyID string) ([]string, bool, error) {
+	query := fmt.Sprintf(`MATCH (s:%[1]s {%[2]s: $id})
+	OPTIONAL MATCH (v:%[3]s) WHERE v.%[4]s = $id
+	RETURN s.id AS studyId, collect(v.id) AS versionIds`,
+		graphschema.Study, graphschema.KeyProperty, graphschema.StudyVersion, graphschema.StudyProperty)
+
+	records, err := readQuery(ctx, query, map[string]any{"id": studyID})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(records) == 0 {
+		return nil, false, nil
+	}
+	ids, _, err := neo4j.GetRecordValue[[]any](records[0], "versionIds")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read version ids of study %s: %w", studyID, err)
+	}
+	versionIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		versionIDs = append(versionIDs, fmt.Sprint(id))
+	}
+	return versionIDs, true, nil
+}
@@ -0,0 +1,76 @@
+package mutations
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/studyevent"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// fakeGraph answers the read of studyVersionIDs with records, and
+// records whether the delete ran.
+func fakeGraph(t *testing.T, records []*neo4j.Record) *bool {
+	t.Helper()
+	deleted := false
+	oldRead, oldWrite, oldEvents := readQuery, writeQuery, Events
+	t.Cleanup(func() { readQuery, writeQuery, Events = oldRead, oldWrite, oldEvents })
+
+	readQuery = func(_ context.Context, query string, _ map[string]any) ([]*neo4j.Record, error) {
+		// An aggregate without a grouping key returns one row even when
+		// nothing matched, which would make every study look found.
+		if !strings.Contains(query, "RETURN s.id AS studyId, collect(") {
+			t.Errorf("version ids are not collected per study:\n%s", query)
+		}
+		return records, nil
+	}
+	writeQuery = func(context.Context, string, map[string]any) error {
+		deleted = true
+		return nil
+	}
+	return &deleted
+}
+
+func TestDeleteStudyPublishesDeleted(t *testing.T) {
+	deleted := fakeGraph(t, []*neo4j.Record{{
+		Keys:   []string{"studyId", "versionIds"},
+		Values: []any{"S1", []any{"V1", "V2"}},
+	}})
+	events := studyevent.NewMemoryPublisher()
+	Events = events
+
+	ok, err := HandleMutationDeleteStudy(context.Background(), map[string]any{"id": "S1"})
+	if err != nil || !ok {
+		t.Fatalf("HandleMutationDeleteStudy = %v, %v", ok, err)
+	}
+	if !*deleted {
+		t.Error("study was not deleted")
+	}
+	got := events.Events()
+	if len(got) != 1 {
+		t.Fatalf("published %d events, want 1", len(got))
+	}
+	if got[0].Type != studyevent.StudyDeleted || got[0].StudyID != "S1" || !slices.Equal(got[0].VersionIDs, []string{"V1", "V2"}) {
+		t.Errorf("published %+v", got[0])
+	}
+}
+
+func TestDeleteStudyNotFound(t *testing.T) {
+	deleted := fakeGraph(t, nil)
+	events := studyevent.NewMemoryPublisher()
+	Events = events
+
+	ok, err := HandleMutationDeleteStudy(context.Background(), map[string]any{"id": "S9"})
+	if err != nil || !ok {
+		t.Fatalf("HandleMutationDeleteStudy = %v, %v", ok, err)
+	}
+	if !*deleted {
+		t.Error("delete did not run")
+	}
+	if got := events.Events(); len(got) != 0 {
+		t.Errorf("published %v for a study that does not exist", got)
+	}
+}
@@ -0,0 +1,215 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FieldArguments maps a selection path, in the same "a/b/c" form AppSync
+// uses for selectionSetList, to the arguments passed on that field.
+type FieldArguments map[string]map[string]any
+
+// Get returns the named argument given on the field at path, or nil.
+func (f FieldArguments) Get(path, name string) any {
+	if f == nil {
+		return nil
+	}
+	return f[path][name]
+}
+
+// ParseFieldArguments extracts the arguments of every nested field from
+// AppSync's selectionSetGraphQL. selectionSetList only carries field paths,
+// so arguments such as organizations(role:) have to be recovered from the
+// raw selection set. Variable references are resolved against variables.
+func ParseFieldArguments(selectionSetGraphQL string, variables map[string]any) FieldArguments {
+	p := &selectionParser{
+		tokens:    tokenize(selectionSetGraphQL),
+		variables: variables,
+		args:      FieldArguments{},
+	}
+	if p.peek() == "{" {
+		p.parseSelectionSet("")
+	}
+	return p.args
+}
+
+type selectionParser struct {
+	tokens    []string
+	pos       int
+	variables map[string]any
+	args      FieldArguments
+}
+
+func (p *selectionParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *selectionParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *selectionParser) parseSelectionSet(prefix string) {
+	p.next() // {
+	for p.pos < len(p.tokens) && p.peek() != "}" {
+		if p.peek() == "..." {
+			p.next()
+			if p.peek() == "on" {
+				p.next() // on
+				p.next() // type condition
+			}
+			p.skipDirectives()
+			if p.peek() == "{" {
+				p.parseSelectionSet(prefix)
+			} else {
+				p.next() // named fragment spread, not expanded
+			}
+			continue
+		}
+
+		name := p.next()
+		if p.peek() == ":" {
+			p.next()
+			name = p.next()
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "/" + name
+		}
+
+		if p.peek() == "(" {
+			p.next()
+			args := map[string]any{}
+			for p.pos < len(p.tokens) && p.peek() != ")" {
+				argName := p.next()
+				p.next() // :
+				args[argName] = p.parseValue()
+			}
+			p.next() // )
+			p.args[path] = args
+		}
+
+		p.skipDirectives()
+		if p.peek() == "{" {
+			p.parseSelectionSet(path)
+		}
+	}
+	p.next() // }
+}
+
+func (p *selectionParser) skipDirectives() {
+	for p.peek() == "@" {
+		p.next()
+		p.next()
+		if p.peek() == "(" {
+			depth := 0
+			for p.pos < len(p.tokens) {
+				t := p.next()
+				if t == "(" {
+					depth++
+				} else if t == ")" {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+func (p *selectionParser) parseValue() any {
+	t := p.next()
+	switch {
+	case t == "$":
+		return p.variables[p.next()]
+	case t == "[":
+		var list []any
+		for p.pos < len(p.tokens) && p.peek() != "]" {
+			list = append(list, p.parseValue())
+		}
+		p.next()
+		return list
+	case t == "{":
+		obj := map[string]any{}
+		for p.pos < len(p.tokens) && p.peek() != "}" {
+			key := p.next()
+			p.next() // :
+			obj[key] = p.parseValue()
+		}
+		p.next()
+		return obj
+	case strings.HasPrefix(t, `"`):
+		s, err := strconv.Unquote(t)
+		if err != nil {
+			return strings.Trim(t, `"`)
+		}
+		return s
+	case t == "true":
+		return true
+	case t == "false":
+		return false
+	case t == "null":
+		return nil
+	}
+	if i, err := strconv.ParseInt(t, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(t, 64); err == nil {
+		return f
+	}
+	// Enum values are passed through as their name.
+	return t
+}
+
+func tokenize(src string) []string {
+	var tokens []string
+	r := []rune(src)
+	for i := 0; i < len(r); {
+		c := r[i]
+		switch {
+		case unicode.IsSpace(c) || c == ',':
+			i++
+		case c == '#':
+			for i < len(r) && r[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			j := i + 1
+			for j < len(r) && r[j] != '"' {
+				if r[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(r) {
+				j++
+			}
+			tokens = append(tokens, string(r[i:j]))
+			i = j
+		case c == '.' && i+2 < len(r) && r[i+1] == '.' && r[i+2] == '.':
+			tokens = append(tokens, "...")
+			i += 3
+		case strings.ContainsRune("{}():[]!$@=", c):
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			j := i
+			for j < len(r) && (unicode.IsLetter(r[j]) || unicode.IsDigit(r[j]) || r[j] == '_' || r[j] == '-' || r[j] == '.') {
+				j++
+			}
+			if j == i {
+				j++
+			}
+			tokens = append(tokens, string(r[i:j]))
+			i = j
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,64 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphread"
+	"github.com/ankit-lilly/dtd-go-backend/internal/neptunedb/gremlin"
+	"github.com/ankit-lilly/dtd-go-backend/internal/studydiff"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+func HandleQueryCompareStudyVersions(ctx context.Context, args map[string]any) (*models.StudyVersionDiff, error) {
+	left, err := versionRef(args, "left")
+	if err != nil {
+		return nil, err
+	}
+	right, err := versionRef(args, "right")
+	if err != nil {
+		return nil, err
+	}
+
+	graphSource := gremlin.GetReaderGraphTraversalSource()
+	if graphSource == nil {
+		return nil, fmt.Errorf("graph source is not initialized")
+	}
+
+	leftVersion, err := graphread.StudyVersion(graphSource, left)
+	if err != nil {
+		return nil, versionError(left, err)
+	}
+	rightVersion, err := graphread.StudyVersion(graphSource, right)
+	if err != nil {
+		return nil, versionError(right, err)
+	}
+
+	return studydiff.Compare(left.String(), leftVersion, right.String(), rightVersion), nil
+}
+
+// versionRef reads one side of a comparison from <side>Id, <side>StudyId
+// and <side>Revision.
+func versionRef(args map[string]any, side string) (graphread.VersionRef, error) {
+	var ref graphread.VersionRef
+	ref.ID, _ = args[side+"Id"].(string)
+	if ref.ID == "" {
+		return ref, fmt.Errorf("%sId is required", side)
+	}
+	ref.StudyID, _ = args[side+"StudyId"].(string)
+	if revision, ok := args[side+"Revision"].(float64); ok {
+		if revision < 1 {
+			return ref, fmt.Errorf("%sRevision must be at least 1", side)
+		}
+		ref.Revision = int(revision)
+	}
+	return ref, nil
+}
+
+func versionError(ref graphread.VersionRef, err error) error {
+	if errors.Is(err, graphread.ErrNotFound) {
+		return fmt.Errorf("study version %s not found", ref)
+	}
+	return fmt.Errorf("failed to read study version %s: %w", ref, err)
+}
@@ -0,0 +1,231 @@
+package query
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+	cursorPrefix    = "offset:"
+)
+
+// listSpec says how the filter arguments of a list query reach a label:
+// codePath is the chain of edges from the node to the Code matched by
+// code/decode.
+type listSpec struct {
+	label    string
+	codePath []string
+}
+
+var (
+	studiesList = listSpec{
+		label:    graphschema.Study,
+		codePath: []string{graphschema.HasVersion, graphschema.IncludesDesign, graphschema.HasPhase, graphschema.HasStandardCode},
+	}
+	activitiesList = listSpec{
+		label:    graphschema.Activity,
+		codePath: []string{graphschema.HasDefinedProcedure, graphschema.HasCode},
+	}
+	encountersList = listSpec{
+		label:    graphschema.Encounter,
+		codePath: []string{graphschema.HasEncounterType},
+	}
+)
+
+// sortFields maps the SortField enum to node properties.
+var sortFields = map[string]string{
+	"ID":    "id",
+	"NAME":  "name",
+	"LABEL": "label",
+}
+
+type listFilter struct {
+	NameContains string
+	InstanceType string
+	StudyID      string
+	Code         string
+	Decode       string
+}
+
+type listSort struct {
+	property   string
+	descending bool
+}
+
+// listArgs are the arguments shared by the studies, activities and
+// encounters connections. Only studies takes revision arguments.
+type listArgs struct {
+	offset   int
+	limit    int
+	filter   listFilter
+	sort     listSort
+	revision revisionArgs
+}
+
+func parseListArgs(args map[string]any) (listArgs, error) {
+	la := listArgs{limit: defaultPageSize, sort: listSort{property: "id"}}
+
+	if first, ok := args["first"].(float64); ok {
+		if first < 0 || first > maxPageSize {
+			return la, fmt.Errorf("first must be between 0 and %d", maxPageSize)
+		}
+		la.limit = int(first)
+	}
+
+	if after, ok := args["after"].(string); ok && after != "" {
+		offset, err := decodeCursor(after)
+		if err != nil {
+			return la, err
+		}
+		la.offset = offset + 1
+	}
+
+	if filter, ok := args["filter"].(map[string]any); ok {
+		la.filter.NameContains, _ = filter["nameContains"].(string)
+		la.filter.InstanceType, _ = filter["instanceType"].(string)
+		la.filter.StudyID, _ = filter["studyId"].(string)
+		la.filter.Code, _ = filter["code"].(string)
+		la.filter.Decode, _ = filter["decode"].(string)
+	}
+
+	revision, err := parseRevisionArgs(args)
+	if err != nil {
+		return la, err
+	}
+	la.revision = revision
+
+	if orderBy, ok := args["orderBy"].(map[string]any); ok {
+		field, _ := orderBy["field"].(string)
+		property, ok := sortFields[field]
+		if !ok {
+			return la, fmt.Errorf("unsupported sort field %q", field)
+		}
+		la.sort.property = property
+		la.sort.descending = orderBy["direction"] == "DESC"
+	}
+
+	return la, nil
+}
+
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return offset, nil
+}
+
+// newConnection builds a page from nodes fetched with one extra row past
+// the limit, which is how hasNextPage is known without a second query.
+func newConnection[T any](nodes []*T, la listArgs, total int) *models.Connection[T] {
+	conn := &models.Connection[T]{
+		Edges:      []*models.Edge[T]{},
+		TotalCount: total,
+	}
+
+	if len(nodes) > la.limit {
+		nodes = nodes[:la.limit]
+		conn.PageInfo.HasNextPage = true
+	}
+	conn.PageInfo.HasPreviousPage = la.offset > 0
+
+	for i, node := range nodes {
+		conn.Edges = append(conn.Edges, &models.Edge[T]{
+			Cursor: encodeCursor(la.offset + i),
+			Node:   node,
+		})
+	}
+
+	if len(conn.Edges) > 0 {
+		conn.PageInfo.StartCursor = &conn.Edges[0].Cursor
+		conn.PageInfo.EndCursor = &conn.Edges[len(conn.Edges)-1].Cursor
+	}
+	return conn
+}
+
+// nodeSelection narrows a connection's selection set to the fields
+// selected on edges/node.
+func nodeSelection(selectionSet []string) []string {
+	var fields []string
+	for _, path := range selectionSet {
+		if rest, ok := strings.CutPrefix(path, "edges/node/"); ok {
+			fields = append(fields, rest)
+		}
+	}
+	return fields
+}
+
+func wantsTotalCount(selectionSet []string) bool {
+	for _, path := range selectionSet {
+		if path == "totalCount" {
+			return true
+		}
+	}
+	return false
+}
+
+// gremlinFilter applies the filter to a traversal over spec.label vertices.
+func gremlinFilter(t *gremlingo.GraphTraversal, spec listSpec, filter listFilter) *gremlingo.GraphTraversal {
+	if filter.NameContains != "" {
+		t = t.Has("name", gremlingo.TextP.Containing(filter.NameContains))
+	}
+	if filter.InstanceType != "" {
+		t = t.Has("instanceType", filter.InstanceType)
+	}
+	if filter.StudyID != "" {
+		t = t.Or(
+			gremlingo.T__.Has(graphschema.StudyProperty, filter.StudyID),
+			gremlingo.T__.Has(graphschema.SnapshotOfProperty, filter.StudyID),
+		)
+	}
+	if filter.Code != "" || filter.Decode != "" {
+		down := gremlingo.T__.Identity()
+		for _, label := range spec.codePath {
+			down = down.Out(label)
+		}
+		if filter.Code != "" {
+			down = down.Has("code", filter.Code)
+		}
+		if filter.Decode != "" {
+			down = down.Has("decode", filter.Decode)
+		}
+		t = t.Where(down)
+	}
+	return t
+}
+
+// gremlinPage orders the traversal, sorting a missing property as the empty
+// string and breaking ties on the key, since document ids repeat across
+// studies. It takes one row past the page so newConnection can tell
+// whether another page follows.
+func gremlinPage(t *gremlingo.GraphTraversal, la listArgs) *gremlingo.GraphTraversal {
+	direction := gremlingo.Order.Asc
+	if la.sort.descending {
+		direction = gremlingo.Order.Desc
+	}
+
+	if la.sort.property == "id" {
+		t = t.Order().By("id", direction).By(graphschema.KeyProperty, gremlingo.Order.Asc)
+	} else {
+		t = t.Order().
+			By(gremlingo.T__.Coalesce(gremlingo.T__.Values(la.sort.property), gremlingo.T__.Constant("")), direction).
+			By(graphschema.KeyProperty, gremlingo.Order.Asc)
+	}
+	return t.Range(int64(la.offset), int64(la.offset+la.limit+1))
+}
@@ -0,0 +1,189 @@
+package query
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, offset := range []int{0, 1, 49, 12345} {
+		cursor := encodeCursor(offset)
+		got, err := decodeCursor(cursor)
+		if err != nil {
+			t.Fatalf("decodeCursor(encodeCursor(%d)): %v", offset, err)
+		}
+		if got != offset {
+			t.Errorf("decodeCursor(encodeCursor(%d)) = %d", offset, got)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsMalformed(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	tests := map[string]string{
+		"not base64":     "!!!",
+		"wrong prefix":   encode("page:3"),
+		"not a number":   encode(cursorPrefix + "three"),
+		"negative":       encode(cursorPrefix + "-1"),
+		"empty offset":   encode(cursorPrefix),
+		"url alphabet":   base64.URLEncoding.EncodeToString([]byte(cursorPrefix + "1>>")),
+		"missing offset": "",
+	}
+	for name, cursor := range tests {
+		t.Run(name, func(t *testing.T) {
+			if offset, err := decodeCursor(cursor); err == nil {
+				t.Errorf("decodeCursor(%q) = %d, want an error", cursor, offset)
+			}
+		})
+	}
+}
+
+func TestParseListArgsPaging(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       map[string]any
+		wantOffset int
+		wantLimit  int
+		wantErr    bool
+	}{
+		{name: "defaults", args: map[string]any{}, wantLimit: defaultPageSize},
+		{name: "first", args: map[string]any{"first": 10.0}, wantLimit: 10},
+		{name: "first at the cap", args: map[string]any{"first": 200.0}, wantLimit: maxPageSize},
+		{name: "first over the cap", args: map[string]any{"first": 201.0}, wantErr: true},
+		{name: "negative first", args: map[string]any{"first": -1.0}, wantErr: true},
+		{name: "after", args: map[string]any{"after": encodeCursor(9)}, wantOffset: 10, wantLimit: defaultPageSize},
+		{name: "empty after", args: map[string]any{"after": ""}, wantLimit: defaultPageSize},
+		{name: "malformed after", args: map[string]any{"after": "bogus"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			la, err := parseListArgs(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseListArgs(%v) succeeded, want an error", tt.args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseListArgs(%v): %v", tt.args, err)
+			}
+			if la.offset != tt.wantOffset || la.limit != tt.wantLimit {
+				t.Errorf("offset, limit = %d, %d, want %d, %d", la.offset, la.limit, tt.wantOffset, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestNewConnection(t *testing.T) {
+	nodes := func(n int) []*string {
+		list := make([]*string, n)
+		for i := range list {
+			s := string(rune('a' + i))
+			list[i] = &s
+		}
+		return list
+	}
+
+	tests := []struct {
+		name         string
+		fetched      int
+		offset       int
+		wantEdges    int
+		wantNext     bool
+		wantPrevious bool
+	}{
+		{name: "more pages", fetched: 3, wantEdges: 2, wantNext: true},
+		{name: "last page exactly full", fetched: 2, offset: 4, wantEdges: 2, wantPrevious: true},
+		{name: "last page short", fetched: 1, offset: 4, wantEdges: 1, wantPrevious: true},
+		{name: "past the end", fetched: 0, offset: 6, wantPrevious: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			la := listArgs{offset: tt.offset, limit: 2}
+			conn := newConnection(nodes(tt.fetched), la, 6)
+
+			if len(conn.Edges) != tt.wantEdges {
+				t.Fatalf("len(Edges) = %d, want %d", len(conn.Edges), tt.wantEdges)
+			}
+			if conn.PageInfo.HasNextPage != tt.wantNext || conn.PageInfo.HasPreviousPage != tt.wantPrevious {
+				t.Errorf("hasNextPage, hasPreviousPage = %t, %t, want %t, %t",
+					conn.PageInfo.HasNextPage, conn.PageInfo.HasPreviousPage, tt.wantNext, tt.wantPrevious)
+			}
+			if conn.TotalCount != 6 {
+				t.Errorf("TotalCount = %d, want 6", conn.TotalCount)
+			}
+			if tt.wantEdges == 0 {
+				if conn.PageInfo.StartCursor != nil || conn.PageInfo.EndCursor != nil {
+					t.Error("an empty page has cursors")
+				}
+				return
+			}
+			for i, edge := range conn.Edges {
+				if offset, _ := decodeCursor(edge.Cursor); offset != tt.offset+i {
+					t.Errorf("edge %d cursor offset = %d, want %d", i, offset, tt.offset+i)
+				}
+			}
+			if *conn.PageInfo.StartCursor != conn.Edges[0].Cursor || *conn.PageInfo.EndCursor != conn.Edges[len(conn.Edges)-1].Cursor {
+				t.Error("start and end cursors are not those of the first and last edges")
+			}
+
+			// Paging on from the end cursor starts right after it.
+			next, err := parseListArgs(map[string]any{"after": *conn.PageInfo.EndCursor})
+			if err != nil {
+				t.Fatalf("parseListArgs(after end cursor): %v", err)
+			}
+			if want := tt.offset + tt.wantEdges; next.offset != want {
+				t.Errorf("next page offset = %d, want %d", next.offset, want)
+			}
+		})
+	}
+}
+
+func TestRevisionVertices(t *testing.T) {
+	g := gremlingo.NewGraphTraversalSource(nil, nil)
+	translate := func(t *testing.T, traversal *gremlingo.GraphTraversal) string {
+		t.Helper()
+		got, err := gremlingo.NewTranslator("g").Translate(traversal.Bytecode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name  string
+		ra    revisionArgs
+		label string
+		want  string
+	}{
+		{"live study", revisionArgs{}, graphschema.Study,
+			"g.V().hasLabel('Study').hasNot('snapshotOf')"},
+		{"live activity", revisionArgs{}, graphschema.Activity,
+			"g.V().hasLabel('Activity').hasNot('snapshotOf')"},
+		{"study revision", revisionArgs{revision: 2}, graphschema.Study,
+			"g.V().hasLabel('Study').has('snapshotOf').has('revision',2)"},
+		{"study asOf", revisionArgs{asOf: "2024-01-01T00:00:00.000Z"}, graphschema.Study,
+			"g.V().hasLabel('Study').has('snapshotOf').has('ingestedAt',lte('2024-01-01T00:00:00.000Z'))." +
+				"not(in('SUPERSEDES').has('ingestedAt',lte('2024-01-01T00:00:00.000Z')))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vertices, err := tt.ra.vertices(g, tt.label, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := translate(t, vertices); got != tt.want {
+				t.Errorf("vertices = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// A revision's other vertices are those written for its snapshot key.
+	got := translate(t, inSnapshots(g.V().HasLabel(graphschema.Activity), []string{"S1@2", "S2@1"}))
+	if want := "g.V().hasLabel('Activity').has('studyId',within(['S1@2','S2@1']))"; got != want {
+		t.Errorf("inSnapshots = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphread"
+	"github.com/ankit-lilly/dtd-go-backend/internal/neptunedb/gremlin"
+	"github.com/ankit-lilly/dtd-go-backend/internal/usdm"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// HandleQueryExportStudy returns the SDR payload that submits a study
+// again, as AWSJSON. versionId keeps one version of it and revision reads
+// one of its revisions; GET /sdr/{id}/usdm exports the same document.
+func HandleQueryExportStudy(ctx context.Context, args map[string]any) (string, error) {
+	study, err := exportedStudy(args)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(usdm.Export(study))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal export of study %s: %w", study.ID, err)
+	}
+	return string(payload), nil
+}
+
+// exportedStudy reads the study an export query names by its id, versionId
+// and revision arguments.
+func exportedStudy(args map[string]any) (models.Study, error) {
+	studyID, ok := args["id"].(string)
+	if !ok || studyID == "" {
+		return models.Study{}, fmt.Errorf("study ID is required")
+	}
+	versionID, _ := args["versionId"].(string)
+	revision := 0
+	if r, ok := args["revision"].(float64); ok {
+		if r < 1 {
+			return models.Study{}, fmt.Errorf("revision must be at least 1")
+		}
+		revision = int(r)
+	}
+
+	graphSource := gremlin.GetReaderGraphTraversalSource()
+	if graphSource == nil {
+		return models.Study{}, fmt.Errorf("graph source is not initialized")
+	}
+
+	study, err := graphread.StudyModel(graphSource, studyID, revision, versionID)
+	if errors.Is(err, graphread.ErrNotFound) {
+		return models.Study{}, fmt.Errorf("study %s not found", studyID)
+	}
+	if err != nil {
+		return models.Study{}, fmt.Errorf("failed to export study %s: %w", studyID, err)
+	}
+	return study, nil
+}
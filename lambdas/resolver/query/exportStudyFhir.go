@@ -0,0 +1,32 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/fhir"
+)
+
+// HandleQueryExportStudyFhir returns a study as a FHIR bundle, as AWSJSON,
+// in the FHIR release the release argument names: R4 unless it is R5. The
+// study is picked as exportStudy picks it; GET /sdr/{id}/fhir exports the
+// same bundle.
+func HandleQueryExportStudyFhir(ctx context.Context, args map[string]any) (string, error) {
+	releaseArg, _ := args["release"].(string)
+	release, err := fhir.ParseRelease(releaseArg)
+	if err != nil {
+		return "", err
+	}
+
+	study, err := exportedStudy(args)
+	if err != nil {
+		return "", err
+	}
+
+	bundle, err := json.Marshal(fhir.Export(study, release))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal FHIR export of study %s: %w", study.ID, err)
+	}
+	return string(bundle), nil
+}
@@ -0,0 +1,57 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphread"
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/internal/neptunedb/gremlin"
+	"github.com/ankit-lilly/dtd-go-backend/internal/rdf"
+)
+
+// RDFBaseIRI is the IRI exportStudyRdf names study resources under; empty
+// means rdf.DefaultBaseIRI.
+var RDFBaseIRI string
+
+// HandleQueryExportStudyRdf returns the subgraph written for a study, or
+// for its revision if that is set, as Turtle or, with format "jsonld",
+// JSON-LD. GET /sdr/{id}/rdf exports the same document.
+func HandleQueryExportStudyRdf(ctx context.Context, args map[string]any) (string, error) {
+	studyID, ok := args["id"].(string)
+	if !ok || studyID == "" {
+		return "", fmt.Errorf("study ID is required")
+	}
+	formatArg, _ := args["format"].(string)
+	format, err := rdf.ParseFormat(formatArg)
+	if err != nil {
+		return "", err
+	}
+	scope := studyID
+	if r, ok := args["revision"].(float64); ok {
+		if r < 1 {
+			return "", fmt.Errorf("revision must be at least 1")
+		}
+		scope = graphschema.SnapshotKey(studyID, int(r))
+	}
+
+	graphSource := gremlin.GetReaderGraphTraversalSource()
+	if graphSource == nil {
+		return "", fmt.Errorf("graph source is not initialized")
+	}
+
+	nodes, edges, err := graphread.Subgraph(graphSource, scope)
+	if errors.Is(err, graphread.ErrNotFound) {
+		return "", fmt.Errorf("study %s not found", scope)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to export study %s: %w", scope, err)
+	}
+
+	body, err := rdf.FromSubgraph(scope, nodes, edges, RDFBaseIRI).Serialize(format)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize study %s: %w", scope, err)
+	}
+	return string(body), nil
+}
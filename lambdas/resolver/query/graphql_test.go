@@ -0,0 +1,89 @@
+package query
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+)
+
+var (
+	graphqlType  = regexp.MustCompile(`(?m)^type (\w+)[^{]*\{([^}]*)\}`)
+	graphqlField = regexp.MustCompile(`(?m)^\s*(\w+)(?:\([^)]*\))?:\s*\[?(\w+)`)
+)
+
+// graphqlTypes reads the object types of schema/schema.graphql as the
+// type each of their fields returns, by field.
+func graphqlTypes(t *testing.T) map[string]map[string]string {
+	t.Helper()
+	raw, err := os.ReadFile("../../../schema/schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := make(map[string]map[string]string)
+	for _, m := range graphqlType.FindAllStringSubmatch(string(raw), -1) {
+		fields := make(map[string]string)
+		for _, f := range graphqlField.FindAllStringSubmatch(m[2], -1) {
+			fields[f[1]] = f[2]
+		}
+		types[m[1]] = fields
+	}
+	return types
+}
+
+// computedFields are filled in by the resolver rather than read from a
+// node the processor wrote.
+var computedFields = map[string]bool{
+	"Study.revision":   true,
+	"Study.ingestedAt": true,
+}
+
+// TestGraphQLFieldsAreWritten walks the GraphQL types from Study the way
+// the projector resolves them and checks that every field is one the
+// schema declares, and so one the processor writes, and that a field
+// returns an object exactly when it walks to other nodes. A type such as
+// Code is read from nodes of several labels and checked against each.
+func TestGraphQLFieldsAreWritten(t *testing.T) {
+	types := graphqlTypes(t)
+	p := newProjector(nil)
+
+	type read struct{ typeName, label string }
+	seen := map[read]bool{{"Study", graphschema.USDM.Root}: true}
+	queue := []read{{"Study", graphschema.USDM.Root}}
+	for len(queue) > 0 {
+		at := queue[0]
+		queue = queue[1:]
+
+		fields := types[at.typeName]
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			if computedFields[at.typeName+"."+name] {
+				continue
+			}
+			r := p.resolve(at.label, name)
+			fieldType := fields[name]
+			_, object := types[fieldType]
+			switch {
+			case r.kind == undeclaredField:
+				t.Errorf("%s.%s is in the GraphQL schema but %s has no such property, edge or reference", at.typeName, name, at.label)
+			case object && r.kind != nodeField:
+				t.Errorf("%s.%s returns %s but reads a property of %s", at.typeName, name, fieldType, at.label)
+			case !object && r.kind == nodeField:
+				t.Errorf("%s.%s returns %s but walks to %s nodes", at.typeName, name, fieldType, r.to)
+			case object:
+				next := read{fieldType, r.to}
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+func HandleQueryOrganization(ctx context.Context, args map[string]any, selectionSet []string, fieldArgs FieldArguments) (*models.Organization, error) {
+	orgID, ok := args["id"].(string)
+	if !ok || orgID == "" {
+		return nil, fmt.Errorf("organization ID is required")
+	}
+
+	studyID, _ := args["studyId"].(string)
+	org, err := fetchByID[models.Organization](graphschema.Organization, orgID, studyID, selectionSet, fieldArgs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query organization %s: %w", orgID, err)
+	}
+	return org, nil
+}
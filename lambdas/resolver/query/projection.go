@@ -0,0 +1,357 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/internal/neptunedb/gremlin"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+)
+
+// selection is a parsed selection set: every selected field maps to its own
+// sub-selection, which is empty for scalars.
+type selection map[string]selection
+
+func parseSelectionSet(selectionSet []string) selection {
+	root := selection{}
+	for _, path := range selectionSet {
+		current := root
+		for _, part := range strings.Split(path, "/") {
+			next, ok := current[part]
+			if !ok {
+				next = selection{}
+				current[part] = next
+			}
+			current = next
+		}
+	}
+	log.Printf("Parsed selection set: %v", root)
+	return root
+}
+
+// fieldFilters narrow the nodes a field resolves to by one of the field's
+// GraphQL arguments: a node is kept if the returned traversal yields
+// anything from it. Keys are "<label>.<field>(<argument>)".
+var fieldFilters = map[string]func(value any) *gremlingo.GraphTraversal{
+	graphschema.StudyVersion + ".organizations(role)": organizationsWithRole,
+}
+
+// organizationsWithRole keeps the organizations whose type has role as its
+// code or decode, in any case.
+func organizationsWithRole(role any) *gremlingo.GraphTraversal {
+	pattern := rolePattern(fmt.Sprint(role))
+	return gremlingo.T__.Out(graphschema.HasOrganizationType).
+		Or(gremlingo.T__.Has("code", gremlingo.TextP.Regex(pattern)), gremlingo.T__.Has("decode", gremlingo.TextP.Regex(pattern)))
+}
+
+// rolePattern matches role as a whole, ignoring case. Gremlin has no
+// toLower, so this is done with a regex, which Neptune evaluates as a Java
+// one; QuoteMeta only escapes punctuation, which Java reads the same way.
+func rolePattern(role string) string {
+	return "(?i)^" + regexp.QuoteMeta(role) + "$"
+}
+
+type fieldKind int
+
+const (
+	scalarField fieldKind = iota
+	nodeField
+	// undeclaredField is a field the schema does not declare, read like a
+	// scalar property the processor never writes.
+	undeclaredField
+)
+
+// resolvedField is how a GraphQL field is read from a vertex of a label:
+// step walks from the vertex to the field's vertices or values.
+type resolvedField struct {
+	kind fieldKind
+	to   string
+	many bool
+	step *gremlingo.GraphTraversal
+}
+
+// projector compiles selection sets into Gremlin project() traversals using
+// the graph schema, so every field the schema declares is readable without
+// field-specific code:
+//
+//   - an Edge field projects its child nodes, recursively;
+//   - a Reference with a Field projects the referenced nodes;
+//   - a Backlink projects the parent nodes;
+//   - a node property is read as a scalar;
+//   - a Reference property such as elementIds is read as the list of ids
+//     of the referenced nodes.
+//
+// Every field is folded so that a missing value yields an empty list
+// rather than dropping the row; shape unfolds the to-one fields afterwards.
+type projector struct {
+	schema *graphschema.Schema
+	args   FieldArguments
+}
+
+func newProjector(fieldArgs FieldArguments) projector {
+	return projector{schema: graphschema.USDM, args: fieldArgs}
+}
+
+func (p projector) resolve(label, field string) resolvedField {
+	if edge, ok := p.schema.Edge(label, field); ok {
+		return resolvedField{
+			kind: nodeField,
+			to:   edge.To,
+			many: edge.Cardinality == graphschema.ToMany,
+			step: gremlingo.T__.Out(edge.Label),
+		}
+	}
+
+	if ref, ok := p.schema.ReferenceField(label, field); ok {
+		return resolvedField{
+			kind: nodeField,
+			to:   ref.To,
+			many: ref.Cardinality == graphschema.ToMany,
+			step: walkReference(ref),
+		}
+	}
+
+	if back, ok := p.schema.Backlink(label, field); ok {
+		return resolvedField{
+			kind: nodeField,
+			to:   back.To,
+			many: back.Cardinality == graphschema.ToMany,
+			step: gremlingo.T__.In(back.Label).HasLabel(back.To),
+		}
+	}
+
+	if node, ok := p.schema.Node(label); ok && node.HasProperty(field) {
+		return resolvedField{kind: scalarField, step: gremlingo.T__.Values(field)}
+	}
+
+	if ref, ok := p.schema.ReferenceProperty(label, field); ok {
+		return resolvedField{
+			kind: scalarField,
+			many: ref.Cardinality == graphschema.ToMany,
+			step: walkReference(ref).Values("id"),
+		}
+	}
+
+	// Not in the schema: read it as a property, which is empty if the
+	// processor never wrote it.
+	return resolvedField{kind: undeclaredField, step: gremlingo.T__.Values(field)}
+}
+
+func walkReference(ref *graphschema.Reference) *gremlingo.GraphTraversal {
+	if ref.Inverse {
+		return gremlingo.T__.In(ref.Label)
+	}
+	return gremlingo.T__.Out(ref.Label)
+}
+
+// traversal projects a vertex of label onto sel. path is the selection
+// path of the vertex, used to look up field arguments.
+func (p projector) traversal(label string, sel selection, path string) *gremlingo.GraphTraversal {
+	fields := selectedFields(sel)
+
+	keys := make([]any, len(fields))
+	for i, f := range fields {
+		keys[i] = f
+	}
+	t := gremlingo.T__.Project(keys...)
+
+	for _, field := range fields {
+		rf := p.resolve(label, field)
+		step := rf.step
+
+		if rf.kind == nodeField {
+			fieldPath := path + field
+			for name, value := range p.args[fieldPath] {
+				if value == nil {
+					continue
+				}
+				filter, ok := fieldFilters[fmt.Sprintf("%s.%s(%s)", label, field, name)]
+				if !ok {
+					log.Printf("Ignoring unsupported argument %s on %s.%s", name, label, field)
+					continue
+				}
+				step = step.Where(filter(value))
+			}
+			step = step.Map(p.traversal(rf.to, sel[field], fieldPath+"/"))
+		}
+
+		t = t.By(step.Fold())
+	}
+	return t
+}
+
+// shape unfolds the to-one fields of a projected value in place.
+func (p projector) shape(label string, sel selection, value any) any {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+
+	for _, field := range selectedFields(sel) {
+		rf := p.resolve(label, field)
+		list, _ := m[field].([]any)
+
+		if rf.kind == nodeField {
+			for i, item := range list {
+				list[i] = p.shape(rf.to, sel[field], item)
+			}
+		}
+
+		if rf.many {
+			m[field] = list
+		} else if len(list) > 0 {
+			m[field] = list[0]
+		} else {
+			m[field] = nil
+		}
+	}
+	return m
+}
+
+// selectedFields returns the fields of sel in a stable order, defaulting to
+// the id for a node selected without fields.
+func selectedFields(sel selection) []string {
+	fields := make([]string, 0, len(sel))
+	for f := range sel {
+		if strings.HasPrefix(f, "__") {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return []string{"id"}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// project runs a traversal over vertices of label and decodes each
+// projected vertex into a T. basePath is the selection path of those
+// vertices within the query, e.g. "edges/node/" for a connection.
+func project[T any](t *gremlingo.GraphTraversal, label string, sel selection, fieldArgs FieldArguments, basePath string) ([]*T, error) {
+	p := newProjector(fieldArgs)
+
+	results, err := t.Map(p.traversal(label, sel, basePath)).ToList()
+	if err != nil {
+		return nil, err
+	}
+
+	processed := make([]any, 0, len(results))
+	for _, result := range results {
+		processed = append(processed, p.shape(label, sel, convertMap(result.Data)))
+	}
+
+	jsonBytes, err := json.Marshal(processed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal results to JSON: %w", err)
+	}
+
+	var items []*T
+	if err := json.Unmarshal(jsonBytes, &items); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON to structs: %w", err)
+	}
+	return items, nil
+}
+
+// fetchByID projects the live vertex of label whose document id is id, or
+// returns nil if there is none. Document ids are only unique within a
+// study: studyID, if set, picks that study's vertex, and otherwise the id
+// must not be used by more than one study.
+func fetchByID[T any](label, id, studyID string, selectionSet []string, fieldArgs FieldArguments) (*T, error) {
+	graphSource := gremlin.GetReaderGraphTraversalSource()
+	if graphSource == nil {
+		return nil, fmt.Errorf("graph source is not initialized")
+	}
+
+	t := graphSource.V().HasLabel(label)
+	if studyID != "" {
+		t = t.Has(graphschema.KeyProperty, graphschema.USDM.Key(label, studyID, id))
+	} else {
+		t = t.Has("id", id).HasNot(graphschema.SnapshotOfProperty)
+	}
+	return fetchOne[T](t, label, id, selectionSet, fieldArgs)
+}
+
+// fetchOne projects the vertex of label with document id id that t finds,
+// or returns nil if there is none.
+func fetchOne[T any](t *gremlingo.GraphTraversal, label, id string, selectionSet []string, fieldArgs FieldArguments) (*T, error) {
+	items, err := project[T](t.Limit(2), label, parseSelectionSet(selectionSet), fieldArgs, "")
+	if err != nil {
+		return nil, err
+	}
+	switch len(items) {
+	case 0:
+		return nil, nil // Not found
+	case 1:
+		return items[0], nil
+	default:
+		return nil, fmt.Errorf("%s %s exists in more than one study, studyId is required", label, id)
+	}
+}
+
+// fetchConnection pages through the vertices of spec.label matching the
+// list arguments.
+func fetchConnection[T any](spec listSpec, args map[string]any, selectionSet []string, fieldArgs FieldArguments) (*models.Connection[T], error) {
+	graphSource := gremlin.GetReaderGraphTraversalSource()
+	if graphSource == nil {
+		return nil, fmt.Errorf("graph source is not initialized")
+	}
+
+	la, err := parseListArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := gremlinFilter(la.revision.pick(graphSource.V().HasLabel(spec.label)), spec, la.filter)
+
+	total := 0
+	if wantsTotalCount(selectionSet) {
+		count, err := matching.Clone().Count().Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s nodes: %w", spec.label, err)
+		}
+		n, err := count.GetInt64()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s count: %w", spec.label, err)
+		}
+		total = int(n)
+	}
+
+	nodes, err := project[T](gremlinPage(matching, la), spec.label,
+		parseSelectionSet(nodeSelection(selectionSet)), fieldArgs, "edges/node/")
+	if err != nil {
+		return nil, err
+	}
+
+	return newConnection(nodes, la, total), nil
+}
+
+func convertMap(i any) any {
+	switch v := i.(type) {
+	case map[any]any:
+		m := make(map[string]any)
+		for key, val := range v {
+			strKey := fmt.Sprintf("%v", key)
+			m[strKey] = convertMap(val)
+		}
+		return m
+	case map[string]any:
+		for key, val := range v {
+			v[key] = convertMap(val)
+		}
+		return v
+	case []any:
+		for i, val := range v {
+			v[i] = convertMap(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
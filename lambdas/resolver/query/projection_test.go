@@ -0,0 +1,104 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+)
+
+// steps renders the steps of a traversal as "operator:arguments".
+func steps(r resolvedField) string {
+	return fmt.Sprintf("%+v", *r.step.Bytecode)
+}
+
+// TestResolveWalksSchemaEdges checks that every field the schema declares
+// is read along the edge, in the direction, the processor writes it; see
+// internal/graphschema's TestUpsertQueriesMatchProjection for the write
+// side.
+func TestResolveWalksSchemaEdges(t *testing.T) {
+	s := graphschema.USDM
+	p := newProjector(nil)
+
+	type walk struct {
+		label, field, operator, edge, to string
+		many                             bool
+	}
+	var walks []walk
+	for _, e := range s.Edges {
+		walks = append(walks, walk{e.From, e.Field, "out", e.Label, e.To, e.Cardinality == graphschema.ToMany})
+	}
+	for _, r := range s.References {
+		operator := "out"
+		if r.Inverse {
+			operator = "in"
+		}
+		if r.Field != "" {
+			walks = append(walks, walk{r.From, r.Field, operator, r.Label, r.To, r.Cardinality == graphschema.ToMany})
+		}
+	}
+	for _, b := range s.Backlinks {
+		walks = append(walks, walk{b.From, b.Field, "in", b.Label, b.To, b.Cardinality == graphschema.ToMany})
+	}
+
+	for _, w := range walks {
+		r := p.resolve(w.label, w.field)
+		if r.kind != nodeField || r.to != w.to || r.many != w.many {
+			t.Errorf("%s.%s resolves to %+v, want %s nodes (many: %t)", w.label, w.field, r, w.to, w.many)
+			continue
+		}
+		want := fmt.Sprintf("{operator:%s arguments:[%s]}", w.operator, w.edge)
+		if got := steps(r); !strings.Contains(got, want) {
+			t.Errorf("%s.%s walks %s, want %s", w.label, w.field, got, want)
+		}
+	}
+}
+
+func TestResolveReadsSchemaProperties(t *testing.T) {
+	p := newProjector(nil)
+	for _, n := range graphschema.USDM.Nodes {
+		for _, property := range append([]string{"id"}, n.Properties...) {
+			if _, ok := graphschema.USDM.ReferenceProperty(n.Label, property); ok {
+				continue
+			}
+			r := p.resolve(n.Label, property)
+			want := fmt.Sprintf("{operator:values arguments:[%s]}", property)
+			if got := steps(r); r.kind != scalarField || !strings.Contains(got, want) {
+				t.Errorf("%s.%s reads %s, want %s", n.Label, property, got, want)
+			}
+		}
+	}
+}
+
+func TestRolePattern(t *testing.T) {
+	tests := []struct {
+		role, value string
+		want        bool
+	}{
+		{"sponsor", "Sponsor", true},
+		{"SPONSOR", "sponsor", true},
+		{"Sponsor", "Co-Sponsor", false},
+		{"sponsor", "Sponsor Contact", false},
+		{"C70793", "c70793", true},
+		{"a.b", "axb", false},
+		{"a.b", "A.B", true},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile(rolePattern(tt.role))
+		if got := re.MatchString(tt.value); got != tt.want {
+			t.Errorf("role %q matches %q: %t, want %t", tt.role, tt.value, got, tt.want)
+		}
+	}
+
+	got, err := gremlingo.NewTranslator("g").Translate(organizationsWithRole("sponsor").Bytecode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `or(has('code',regex('(?i)^sponsor$')),has('decode',regex('(?i)^sponsor$')))`
+	if !strings.Contains(got, want) {
+		t.Errorf("organizationsWithRole(sponsor) = %s, want code and decode matched by %s", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+package query
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+)
+
+// revisionArgs pick a revision of a study: revision by its number and asOf
+// as the latest revision ingested at or before a time. Without either the
+// live study is read. See graphschema.SnapshotQueries for how revisions
+// are stored.
+type revisionArgs struct {
+	revision int
+	asOf     string
+}
+
+func parseRevisionArgs(args map[string]any) (revisionArgs, error) {
+	var ra revisionArgs
+
+	if revision, ok := args["revision"].(float64); ok {
+		if revision < 1 {
+			return ra, fmt.Errorf("revision must be at least 1")
+		}
+		ra.revision = int(revision)
+	}
+
+	if asOf, ok := args["asOf"].(string); ok && asOf != "" {
+		if ra.revision > 0 {
+			return ra, fmt.Errorf("revision and asOf cannot be used together")
+		}
+		t, err := time.Parse(time.RFC3339, asOf)
+		if err != nil {
+			return ra, fmt.Errorf("asOf must be an RFC 3339 timestamp: %w", err)
+		}
+		ra.asOf = graphschema.FormatIngestedAt(t)
+	}
+
+	return ra, nil
+}
+
+func (ra revisionArgs) live() bool {
+	return ra.revision == 0 && ra.asOf == ""
+}
+
+// pick narrows a traversal over vertices of the root label to the live
+// studies or to the revisions ra picks, at most one per study. Only roots
+// are linked by SUPERSEDES, so a revision's other vertices are found by
+// vertices instead.
+func (ra revisionArgs) pick(t *gremlingo.GraphTraversal) *gremlingo.GraphTraversal {
+	switch {
+	case ra.live():
+		return t.HasNot(graphschema.SnapshotOfProperty)
+	case ra.revision > 0:
+		return t.Has(graphschema.SnapshotOfProperty).Has(graphschema.RevisionProperty, int64(ra.revision))
+	default:
+		asOf := gremlingo.P.Lte(ra.asOf)
+		return t.Has(graphschema.SnapshotOfProperty).
+			Has(graphschema.IngestedAtProperty, asOf).
+			Not(gremlingo.T__.In(graphschema.Supersedes).Has(graphschema.IngestedAtProperty, asOf))
+	}
+}
+
+// vertices returns the vertices of label that are live or in the
+// revisions ra picks, of the study studyID if it is set and of every study
+// otherwise. For any label but the root the revisions are picked first,
+// and their vertices are those written for the revisions' snapshot keys.
+func (ra revisionArgs) vertices(g *gremlingo.GraphTraversalSource, label, studyID string) (*gremlingo.GraphTraversal, error) {
+	t := g.V().HasLabel(label)
+	if label == graphschema.USDM.Root || ra.live() {
+		return ra.pick(t), nil
+	}
+
+	roots := ra.pick(g.V().HasLabel(graphschema.USDM.Root))
+	if studyID != "" {
+		roots = roots.Has(graphschema.SnapshotOfProperty, studyID)
+	}
+	results, err := roots.Values(graphschema.KeyProperty).ToList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query study revisions: %w", err)
+	}
+	keys := make([]string, 0, len(results))
+	for _, r := range results {
+		keys = append(keys, r.GetString())
+	}
+	return inSnapshots(t, keys), nil
+}
+
+// inSnapshots narrows t to the vertices written for one of the snapshot
+// keys.
+func inSnapshots(t *gremlingo.GraphTraversal, keys []string) *gremlingo.GraphTraversal {
+	within := make([]any, len(keys))
+	for i, k := range keys {
+		within[i] = k
+	}
+	return t.Has(graphschema.StudyProperty, gremlingo.P.Within(within...))
+}
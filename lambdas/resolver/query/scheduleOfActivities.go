@@ -0,0 +1,121 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/internal/usdm"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// soaSelection is what the grid needs from a study design regardless of
+// what the client asked for: chain ids to order by and the instances and
+// timings the cells are built from.
+var soaSelection = []string{
+	"id",
+	"epochs/id", "epochs/previousId", "epochs/nextId",
+	"encounters/id", "encounters/previousId", "encounters/nextId",
+	"activities/id", "activities/previousId", "activities/nextId",
+	"scheduleTimelines/id",
+	"scheduleTimelines/mainTimeline",
+	"scheduleTimelines/instances/id",
+	"scheduleTimelines/instances/activityIds",
+	"scheduleTimelines/instances/encounterId",
+	"scheduleTimelines/instances/epochId",
+	"scheduleTimelines/timings/id",
+	"scheduleTimelines/timings/relativeFromScheduledInstanceId",
+}
+
+func HandleQueryScheduleOfActivities(ctx context.Context, args map[string]any, selectionSet []string) (*models.ScheduleOfActivities, error) {
+	designID, ok := args["studyDesignId"].(string)
+	if !ok || designID == "" {
+		return nil, fmt.Errorf("study design ID is required")
+	}
+
+	studyID, _ := args["studyId"].(string)
+	design, err := fetchByID[models.StudyDesign](graphschema.StudyDesign, designID, studyID, designSelection(selectionSet), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedule of activities for study design %s: %w", designID, err)
+	}
+	if design == nil {
+		return nil, nil // Not found
+	}
+
+	soa := &models.ScheduleOfActivities{
+		StudyDesignID: design.ID,
+		Epochs: usdm.ChainOrder(design.Epochs, func(e *models.Epoch) (string, *string, *string) {
+			return e.ID, e.PreviousID, e.NextID
+		}),
+		Encounters: usdm.ChainOrder(design.Encounters, func(e *models.Encounter) (string, *string, *string) {
+			return e.ID, e.PreviousID, e.NextID
+		}),
+		Activities: usdm.ChainOrder(design.Activities, func(a *models.Activity) (string, *string, *string) {
+			return a.ID, a.PreviousID, a.NextID
+		}),
+		Cells: []*models.SoACell{},
+	}
+
+	for _, timeline := range mainTimelinesFirst(design.ScheduleTimelines) {
+		timingFrom := make(map[string]*models.Timing)
+		for _, timing := range timeline.Timings {
+			if from := timing.RelativeFromScheduledInstanceID; from != nil {
+				if _, seen := timingFrom[*from]; !seen {
+					timingFrom[*from] = timing
+				}
+			}
+		}
+
+		for _, instance := range timeline.Instances {
+			if instance.EncounterID == nil {
+				continue
+			}
+			for _, activityID := range instance.ActivityIDs {
+				soa.Cells = append(soa.Cells, &models.SoACell{
+					ActivityID:          activityID,
+					EncounterID:         *instance.EncounterID,
+					EpochID:             instance.EpochID,
+					ScheduledInstanceID: instance.ID,
+					TimelineID:          timeline.ID,
+					Timing:              timingFrom[instance.ID],
+				})
+			}
+		}
+	}
+
+	return soa, nil
+}
+
+// designSelection maps the client's selection on the grid onto a study
+// design: epochs, encounters and activities are read as selected and
+// cells/timing becomes the selection on the timelines' timings.
+func designSelection(selectionSet []string) []string {
+	paths := append([]string(nil), soaSelection...)
+	for _, path := range selectionSet {
+		switch {
+		case strings.HasPrefix(path, "epochs/"),
+			strings.HasPrefix(path, "encounters/"),
+			strings.HasPrefix(path, "activities/"):
+			paths = append(paths, path)
+		case strings.HasPrefix(path, "cells/timing/"):
+			paths = append(paths, "scheduleTimelines/timings/"+strings.TrimPrefix(path, "cells/timing/"))
+		}
+	}
+	return paths
+}
+
+func mainTimelinesFirst(timelines []*models.ScheduleTimeline) []*models.ScheduleTimeline {
+	ordered := make([]*models.ScheduleTimeline, 0, len(timelines))
+	for _, t := range timelines {
+		if t.MainTimeline {
+			ordered = append(ordered, t)
+		}
+	}
+	for _, t := range timelines {
+		if !t.MainTimeline {
+			ordered = append(ordered, t)
+		}
+	}
+	return ordered
+}
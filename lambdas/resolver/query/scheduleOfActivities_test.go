@@ -0,0 +1,75 @@
+package query
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+func TestMainTimelinesFirst(t *testing.T) {
+	timelines := []*models.ScheduleTimeline{
+		{ID: "T1"}, {ID: "T2", MainTimeline: true}, {ID: "T3"}, {ID: "T4", MainTimeline: true},
+	}
+	var got []string
+	for _, timeline := range mainTimelinesFirst(timelines) {
+		got = append(got, timeline.ID)
+	}
+	if want := []string{"T2", "T4", "T1", "T3"}; !slices.Equal(got, want) {
+		t.Errorf("mainTimelinesFirst = %v, want %v", got, want)
+	}
+}
+
+func TestDesignSelection(t *testing.T) {
+	got := designSelection([]string{
+		"studyDesignId",
+		"epochs/name",
+		"encounters/label",
+		"activities/description",
+		"cells/activityId",
+		"cells/timing/value",
+		"cells/timing/relativeToFrom/decode",
+	})
+
+	want := append(slices.Clone(soaSelection),
+		"epochs/name",
+		"encounters/label",
+		"activities/description",
+		"scheduleTimelines/timings/value",
+		"scheduleTimelines/timings/relativeToFrom/decode",
+	)
+	if !slices.Equal(got, want) {
+		t.Errorf("designSelection =\n%v\nwant\n%v", got, want)
+	}
+}
+
+// TestDesignSelectionIsInSchema checks that every path the grid reads
+// from a study design, including a cell's timing mapped onto the
+// timelines' timings, is one the schema declares, so none of them is read
+// as an absent property.
+func TestDesignSelectionIsInSchema(t *testing.T) {
+	p := newProjector(nil)
+	paths := designSelection([]string{"cells/timing/value", "cells/timing/relativeToFrom/decode"})
+	for _, path := range paths {
+		label := graphschema.StudyDesign
+		fields := strings.Split(path, "/")
+		for i, field := range fields {
+			if i < len(fields)-1 {
+				r := p.resolve(label, field)
+				if r.kind != nodeField {
+					t.Errorf("%s: %s.%s is not a node field", path, label, field)
+					break
+				}
+				label = r.to
+				continue
+			}
+			node, _ := p.schema.Node(label)
+			_, ref := p.schema.ReferenceProperty(label, field)
+			if !ref && (node == nil || !node.HasProperty(field)) {
+				t.Errorf("%s: %s has no property %s", path, label, field)
+			}
+		}
+	}
+}
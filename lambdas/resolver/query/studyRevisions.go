@@ -0,0 +1,37 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/internal/neptunedb/gremlin"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+)
+
+// HandleQueryStudyRevisions lists the revisions of a study, latest first.
+func HandleQueryStudyRevisions(ctx context.Context, args map[string]any, selectionSet []string, fieldArgs FieldArguments) ([]*models.Study, error) {
+	studyID, ok := args["id"].(string)
+	if !ok || studyID == "" {
+		return nil, fmt.Errorf("study ID is required")
+	}
+
+	graphSource := gremlin.GetReaderGraphTraversalSource()
+	if graphSource == nil {
+		return nil, fmt.Errorf("graph source is not initialized")
+	}
+
+	revisions, err := project[models.Study](
+		graphSource.V().HasLabel(graphschema.Study).
+			Has(graphschema.SnapshotOfProperty, studyID).
+			Order().By(graphschema.RevisionProperty, gremlingo.Order.Desc),
+		graphschema.Study, parseSelectionSet(selectionSet), fieldArgs, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions of study %s: %w", studyID, err)
+	}
+	if revisions == nil {
+		revisions = []*models.Study{}
+	}
+	return revisions, nil
+}
@@ -0,0 +1,23 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+func HandleQueryStudyVersion(ctx context.Context, args map[string]any, selectionSet []string, fieldArgs FieldArguments) (*models.StudyVersion, error) {
+	versionID, ok := args["id"].(string)
+	if !ok || versionID == "" {
+		return nil, fmt.Errorf("study version ID is required")
+	}
+
+	studyID, _ := args["studyId"].(string)
+	version, err := fetchByID[models.StudyVersion](graphschema.StudyVersion, versionID, studyID, selectionSet, fieldArgs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query study version %s: %w", versionID, err)
+	}
+	return version, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+)
+
+// entry is one SDR payload found in an ingestion file. name says where it
+// was found: "studies.ndjson:12" is line 12, "legacy.json[3]" the fourth
+// element of a JSON array and "batch.zip!a/study.json" an archive member.
+type entry struct {
+	name string
+	body []byte
+}
+
+// readEntries calls fn for every payload in the file called name, read
+// from r. The extension decides the format:
+//
+//   - .json holds one payload, or a JSON array of payloads;
+//   - .ndjson and .jsonl hold one payload per line;
+//   - .gz is gunzipped and read by the extension it had before;
+//   - .zip is read member by member, skipping what is neither of the above.
+func readEntries(name string, r io.Reader, fn func(entry) error) error {
+	switch ext := strings.ToLower(path.Ext(name)); ext {
+	case ".gz":
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("failed to open gzip %s: %w", name, err)
+		}
+		defer gz.Close()
+		return readEntries(strings.TrimSuffix(name, path.Ext(name)), gz, fn)
+	case ".zip":
+		return readZip(name, r, fn)
+	case ".ndjson", ".jsonl":
+		return readLines(name, r, fn)
+	case ".json":
+		return readJSON(name, r, fn)
+	default:
+		return fmt.Errorf("unsupported file type %q, expected .json, .ndjson, .jsonl, .gz or .zip", ext)
+	}
+}
+
+func readJSON(name string, r io.Reader, fn func(entry) error) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return fn(entry{name: name, body: data})
+	}
+
+	var payloads []json.RawMessage
+	if err := json.Unmarshal(data, &payloads); err != nil {
+		return fmt.Errorf("failed to parse JSON array in %s: %w", name, err)
+	}
+	for i, payload := range payloads {
+		if err := fn(entry{name: fmt.Sprintf("%s[%d]", name, i), body: payload}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readLines(name string, r io.Reader, fn func(entry) error) error {
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read %s line %d: %w", name, line, err)
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+			if fnErr := fn(entry{name: fmt.Sprintf("%s:%d", name, line), body: trimmed}); fnErr != nil {
+				return fnErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+}
+
+// readZip reads the whole archive into memory, since its directory is at
+// the end.
+func readZip(name string, r io.Reader, fn func(entry) error) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("failed to open zip %s: %w", name, err)
+	}
+
+	for _, member := range archive.File {
+		memberName := name + "!" + member.Name
+		if member.FileInfo().IsDir() || !supported(member.Name) {
+			log.Printf("Skipping %s", memberName)
+			continue
+		}
+
+		rc, err := member.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", memberName, err)
+		}
+		err = readEntries(memberName, rc, fn)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// supported reports whether an archive member holds payloads, leaving out
+// the metadata archivers add, such as __MACOSX/ and dot files.
+func supported(member string) bool {
+	base := path.Base(member)
+	if strings.HasPrefix(base, ".") || strings.HasPrefix(member, "__MACOSX/") {
+		return false
+	}
+	switch strings.ToLower(path.Ext(base)) {
+	case ".json", ".ndjson", ".jsonl", ".gz":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	if _, err := gz.Write([]byte(s)); err != nil {
+		t.Fatalf("failed to gzip: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to gzip: %v", err)
+	}
+	return b.Bytes()
+}
+
+// zipped builds an archive of members given as name, content pairs.
+func zipped(t *testing.T, members ...string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for i := 0; i < len(members); i += 2 {
+		f, err := w.Create(members[i])
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", members[i], err)
+		}
+		if _, err := f.Write([]byte(members[i+1])); err != nil {
+			t.Fatalf("failed to write %s: %v", members[i], err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to zip: %v", err)
+	}
+	return b.Bytes()
+}
+
+func TestReadEntries(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data func(t *testing.T) []byte
+		want []string // entry name=body
+	}{
+		{
+			name: "json object",
+			file: "study.json",
+			data: func(*testing.T) []byte { return []byte(`{"id":"S1"}`) },
+			want: []string{`study.json={"id":"S1"}`},
+		},
+		{
+			name: "json array",
+			file: "legacy.json",
+			data: func(*testing.T) []byte { return []byte(` [{"id":"S1"}, {"id":"S2"}]`) },
+			want: []string{`legacy.json[0]={"id":"S1"}`, `legacy.json[1]={"id":"S2"}`},
+		},
+		{
+			name: "ndjson skipping blank lines",
+			file: "studies.ndjson",
+			data: func(*testing.T) []byte { return []byte("{\"id\":\"S1\"}\n\n  {\"id\":\"S2\"}  \n{\"id\":\"S3\"}") },
+			want: []string{`studies.ndjson:1={"id":"S1"}`, `studies.ndjson:3={"id":"S2"}`, `studies.ndjson:4={"id":"S3"}`},
+		},
+		{
+			name: "jsonl",
+			file: "studies.JSONL",
+			data: func(*testing.T) []byte { return []byte("{\"id\":\"S1\"}\n") },
+			want: []string{`studies.JSONL:1={"id":"S1"}`},
+		},
+		{
+			name: "gzipped ndjson",
+			file: "studies.ndjson.gz",
+			data: func(t *testing.T) []byte { return gzipped(t, "{\"id\":\"S1\"}\n{\"id\":\"S2\"}\n") },
+			want: []string{`studies.ndjson:1={"id":"S1"}`, `studies.ndjson:2={"id":"S2"}`},
+		},
+		{
+			name: "zip",
+			file: "batch.zip",
+			data: func(t *testing.T) []byte {
+				return zipped(t,
+					"a/study.json", `{"id":"S1"}`,
+					"a/more.jsonl", "{\"id\":\"S2\"}\n",
+					"README.txt", "not a payload",
+					"__MACOSX/a/._study.json", "resource fork",
+					"a/.hidden.json", `{"id":"S9"}`,
+				)
+			},
+			want: []string{`batch.zip!a/study.json={"id":"S1"}`, `batch.zip!a/more.jsonl:1={"id":"S2"}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := readEntries(tt.file, bytes.NewReader(tt.data(t)), func(e entry) error {
+				got = append(got, e.name+"="+string(e.body))
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("readEntries: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("entries = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadEntriesErrors(t *testing.T) {
+	tests := []struct {
+		file string
+		data string
+	}{
+		{"study.xml", "<study/>"},
+		{"study.json.gz", "not gzip"},
+		{"batch.zip", "not zip"},
+		{"legacy.json", `[{"id":"S1"},`},
+	}
+	for _, tt := range tests {
+		err := readEntries(tt.file, strings.NewReader(tt.data), func(entry) error { return nil })
+		if err == nil {
+			t.Errorf("readEntries(%s) succeeded, want an error", tt.file)
+		}
+	}
+
+	// An error from fn stops the read.
+	stop := errors.New("stop")
+	calls := 0
+	err := readEntries("studies.ndjson", strings.NewReader("{}\n{}\n{}\n"), func(entry) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("readEntries returned %v after %d calls, want stop after 1", err, calls)
+	}
+}
+
+func TestHandlerSkipsReports(t *testing.T) {
+	for key, want := range map[string]bool{
+		"drops/studies.ndjson":             false,
+		"drops/studies.ndjson.report.json": true,
+		"drops/":                           true,
+		"study.json":                       false,
+	} {
+		if got := skipped(key); got != want {
+			t.Errorf("skipped(%q) = %t, want %t", key, got, want)
+		}
+	}
+
+	// Only reports and folders: nothing is fetched, so no S3 client is
+	// needed.
+	event := events.S3Event{Records: []events.S3EventRecord{
+		{S3: events.S3Entity{Bucket: events.S3Bucket{Name: "b"}, Object: events.S3Object{Key: "drops/a.json.report.json"}}},
+		{S3: events.S3Entity{Bucket: events.S3Bucket{Name: "b"}, Object: events.S3Object{Key: "drops%2F"}}},
+	}}
+	if err := handler(context.Background(), event); err != nil {
+		t.Errorf("handler: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/claimcheck"
+	"github.com/ankit-lilly/dtd-go-backend/internal/intake"
+	"github.com/ankit-lilly/dtd-go-backend/internal/submission"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// reportSuffix is appended to the key of an ingested file to name its
+// report. The bucket notifies on every new object, reports included, so
+// keys with this suffix are never ingested.
+const reportSuffix = ".report.json"
+
+// Report is written next to every ingested file. Error is set when the
+// file could not be read to the end; Entries then covers what was read
+// before that.
+type Report struct {
+	Bucket     string        `json:"bucket"`
+	Key        string        `json:"key"`
+	StartedAt  time.Time     `json:"startedAt"`
+	FinishedAt time.Time     `json:"finishedAt"`
+	Total      int           `json:"total"`
+	Accepted   int           `json:"accepted"`
+	Rejected   int           `json:"rejected"`
+	Failed     int           `json:"failed"`
+	Error      string        `json:"error,omitempty"`
+	Entries    []ReportEntry `json:"entries"`
+}
+
+type ReportEntry struct {
+	Entry string `json:"entry"`
+	intake.Result
+}
+
+func (r *Report) add(name string, result intake.Result) {
+	r.Entries = append(r.Entries, ReportEntry{Entry: name, Result: result})
+	r.Total++
+	switch result.Status {
+	case intake.Accepted:
+		r.Accepted++
+	case intake.Rejected:
+		r.Rejected++
+	default:
+		r.Failed++
+	}
+}
+
+var (
+	s3Client *s3.Client
+	sdrs     *intake.Intake
+)
+
+func handler(ctx context.Context, event events.S3Event) error {
+	for _, record := range event.Records {
+		bucket := record.S3.Bucket.Name
+
+		// Keys arrive URL-encoded in S3 event notifications.
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			return fmt.Errorf("failed to decode object key %q: %w", record.S3.Object.Key, err)
+		}
+		if skipped(key) {
+			continue
+		}
+
+		if err := ingest(ctx, bucket, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipped reports whether key is not an ingestion file: one of the
+// reports ingest writes, or a folder.
+func skipped(key string) bool {
+	return strings.HasSuffix(key, reportSuffix) || strings.HasSuffix(key, "/")
+}
+
+// ingest submits every payload in s3://bucket/key and writes the report.
+// Only a failure to fetch the file is returned, which lets Lambda retry
+// the event: once payloads have been queued a retry would queue them again,
+// so a report that cannot be written is logged instead.
+func ingest(ctx context.Context, bucket, key string) error {
+	log.Printf("Ingesting s3://%s/%s", bucket, key)
+
+	object, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get s3://%s/%s: %w", bucket, key, err)
+	}
+	defer object.Body.Close()
+
+	report := &Report{Bucket: bucket, Key: key, StartedAt: time.Now().UTC(), Entries: []ReportEntry{}}
+
+	err = readEntries(key, object.Body, func(e entry) error {
+		report.add(e.name, sdrs.Submit(ctx, e.body))
+		return nil
+	})
+	if err != nil {
+		log.Printf("Stopped reading s3://%s/%s: %v", bucket, key, err)
+		report.Error = err.Error()
+	}
+	report.FinishedAt = time.Now().UTC()
+
+	log.Printf("Ingested s3://%s/%s: %d payloads, %d accepted, %d rejected, %d failed",
+		bucket, key, report.Total, report.Accepted, report.Rejected, report.Failed)
+
+	if err := writeReport(ctx, report); err != nil {
+		log.Printf("Failed to write report for s3://%s/%s: %v", bucket, key, err)
+	}
+	return nil
+}
+
+func writeReport(ctx context.Context, report *Report) error {
+	body, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	_, err = s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(report.Bucket),
+		Key:         aws.String(report.Key + reportSuffix),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put report: %w", err)
+	}
+	return nil
+}
+
+func main() {
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	tableName := os.Getenv(submission.TableEnv)
+	if tableName == "" {
+		log.Fatalf("%s environment variable is not set.", submission.TableEnv)
+	}
+	queueURL := os.Getenv(intake.QueueURLEnv)
+	if queueURL == "" {
+		log.Fatalf("%s environment variable is not set.", intake.QueueURLEnv)
+	}
+
+	s3Client = s3.NewFromConfig(cfg)
+	payloads := claimcheck.NewStore(cfg, os.Getenv(claimcheck.BucketEnv))
+	sdrs = intake.New(submission.NewDynamoStore(cfg, tableName), intake.NewSQSQueue(cfg, queueURL, payloads))
+
+	lambda.Start(handler)
+}
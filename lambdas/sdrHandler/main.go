@@ -3,64 +3,128 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+	"errors"
+	"github.com/ankit-lilly/dtd-go-backend/internal/claimcheck"
+	"github.com/ankit-lilly/dtd-go-backend/internal/intake"
+	"github.com/ankit-lilly/dtd-go-backend/internal/submission"
+	"github.com/ankit-lilly/dtd-go-backend/internal/usdm"
 	"log"
 	"os"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
 )
 
-type IncomingPayload struct {
-	Study models.Study `json:"study"`
+type ValidationErrorResponse struct {
+	Message     string           `json:"message"`
+	UsdmVersion string           `json:"usdmVersion,omitempty"`
+	Violations  []usdm.Violation `json:"violations"`
 }
 
-func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-
-	var payload IncomingPayload
-
-	queueURL := os.Getenv("QUEUE_URL")
+type SubmissionResponse struct {
+	Message      string            `json:"message"`
+	SubmissionID string            `json:"submissionId"`
+	Status       submission.Status `json:"status"`
+}
 
-	if queueURL == "" {
-		log.Fatal("QUEUE_URL environment variable is not set.")
+func jsonResponse(statusCode int, body any) events.APIGatewayProxyResponse {
+	b, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("Failed to marshal response body: %v", err)
+		return events.APIGatewayProxyResponse{StatusCode: 500}
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(b),
 	}
+}
 
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	cfg, err := config.LoadDefaultConfig(context.TODO())
 	if err != nil {
 		log.Fatalf("unable to load SDK config, %v", err)
 	}
 
-	q := sqs.NewFromConfig(cfg)
+	tableName := os.Getenv(submission.TableEnv)
+	if tableName == "" {
+		log.Fatalf("%s environment variable is not set.", submission.TableEnv)
+	}
+	store := submission.NewDynamoStore(cfg, tableName)
+
+	if request.HTTPMethod == "GET" {
+		return getSubmission(ctx, store, request.PathParameters["id"])
+	}
 
-	if err := json.Unmarshal([]byte(request.Body), &payload); err != nil {
-		log.Println("Failed to unmarshal request body:", err)
+	queueURL := os.Getenv(intake.QueueURLEnv)
+
+	if queueURL == "" {
+		log.Fatalf("%s environment variable is not set.", intake.QueueURLEnv)
+	}
+
+	payloads := claimcheck.NewStore(cfg, os.Getenv(claimcheck.BucketEnv))
+
+	return submit(ctx, intake.New(store, intake.NewSQSQueue(cfg, queueURL, payloads)), request.Body)
+}
+
+func submit(ctx context.Context, in *intake.Intake, body string) (events.APIGatewayProxyResponse, error) {
+	result := in.Submit(ctx, []byte(body))
+
+	switch {
+	case result.Status == intake.Accepted:
+		return jsonResponse(202, SubmissionResponse{
+			Message:      "SDR Successfully submitted. Use GET /sdr/" + result.SubmissionID + " to follow its processing.",
+			SubmissionID: result.SubmissionID,
+			Status:       submission.Queued,
+		}), nil
+
+	case result.Status == intake.Rejected && len(result.Violations) > 0:
+		return jsonResponse(400, ValidationErrorResponse{
+			Message:     "SDR does not conform to the USDM schema for its usdmVersion.",
+			UsdmVersion: result.UsdmVersion,
+			Violations:  result.Violations,
+		}), nil
+
+	case result.Status == intake.Rejected:
+		log.Println("Failed to unmarshal request body:", result.Error)
 		return events.APIGatewayProxyResponse{
 			StatusCode: 400,
 			Body:       "Invalid request body. Please provide a valid JSON payload.",
 		}, nil
 	}
 
-	output, err := q.SendMessage(ctx, &sqs.SendMessageInput{
-		QueueUrl:    &queueURL,
-		MessageBody: &request.Body,
-	})
+	log.Printf("Failed to submit SDR %s: %s", result.StudyID, result.Error)
+	return events.APIGatewayProxyResponse{
+		StatusCode: 500,
+		Body:       "Failed to submit SDR. Please try again later.",
+	}, nil
+}
+
+func getSubmission(ctx context.Context, store submission.Store, id string) (events.APIGatewayProxyResponse, error) {
+	if id == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       "Submission id is required.",
+		}, nil
+	}
 
+	record, err := store.Get(ctx, id)
+	if errors.Is(err, submission.ErrNotFound) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 404,
+			Body:       "Submission " + id + " not found.",
+		}, nil
+	}
 	if err != nil {
-		log.Printf("Failed to send message to SQS: %v", err)
+		log.Printf("Failed to get submission %s: %v", id, err)
 		return events.APIGatewayProxyResponse{
 			StatusCode: 500,
-			Body:       "Failed to submit SDR. Please try again later.",
+			Body:       "Failed to get submission. Please try again later.",
 		}, nil
 	}
 
-	log.Printf("Message sent to SQS with ID: %s", *output.MessageId)
-
-	return events.APIGatewayProxyResponse{
-		StatusCode: 202,
-		Body:       "SDR Successfully submitted. You'll receive a confirmation once it's processed.",
-	}, nil
+	return jsonResponse(200, record), nil
 }
 
 func main() {
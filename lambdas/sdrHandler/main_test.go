@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/submission"
+)
+
+// failingStore is a submission.Store whose Get always fails.
+type failingStore struct{ submission.Store }
+
+func (failingStore) Get(context.Context, string) (*submission.Record, error) {
+	return nil, errors.New("table unavailable")
+}
+
+func TestGetSubmission(t *testing.T) {
+	ctx := context.Background()
+	store := submission.NewMemoryStore()
+	if err := store.Create(ctx, submission.Record{ID: "sub-1", StudyID: "S1"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := store.MarkProcessing(ctx, "sub-1"); err != nil {
+		t.Fatalf("MarkProcessing: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		store  submission.Store
+		id     string
+		status int
+	}{
+		{"found", store, "sub-1", 200},
+		{"not found", store, "sub-2", 404},
+		{"missing id", store, "", 400},
+		{"store error", failingStore{}, "sub-1", 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := getSubmission(ctx, tt.store, tt.id)
+			if err != nil {
+				t.Fatalf("getSubmission: %v", err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, resp.Body)
+			}
+			if tt.status != 200 {
+				return
+			}
+			var record submission.Record
+			if err := json.Unmarshal([]byte(resp.Body), &record); err != nil {
+				t.Fatalf("failed to unmarshal body: %v", err)
+			}
+			if record.ID != "sub-1" || record.StudyID != "S1" || record.Status != submission.Processing || record.Attempts != 1 {
+				t.Errorf("record = %+v, want sub-1 of S1 processing on attempt 1", record)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/submission"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// record returns a message of the given study, carried in its attribute,
+// or with the given body if studyID is empty.
+func record(id, studyID, body string) events.SQSMessage {
+	m := events.SQSMessage{MessageId: id, Body: body}
+	if studyID != "" {
+		m.MessageAttributes = map[string]events.SQSMessageAttribute{
+			submission.StudyIDAttribute: {StringValue: &studyID, DataType: "String"},
+		}
+	}
+	return m
+}
+
+func TestGroupByStudy(t *testing.T) {
+	records := []events.SQSMessage{
+		record("0", "S1", ""),
+		record("1", "S2", ""),
+		record("2", "", `{"study":{"id":"S1"}}`), // study read from the body
+		record("3", "", "not json"),
+		record("4", "S1", ""),
+		record("5", "", `{"usdmVersion":"3.0.0"}`),
+	}
+	want := [][]int{{0, 2, 4}, {1}, {3, 5}}
+	if got := groupByStudy(records); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("groupByStudy = %v, want %v", got, want)
+	}
+}
+
+func TestForEachStudyKeepsStudyOrder(t *testing.T) {
+	var records []events.SQSMessage
+	for i := range 12 {
+		records = append(records, record(fmt.Sprint(i), fmt.Sprintf("S%d", i%3), ""))
+	}
+	records = append(records, record("u1", "", "not json"), record("u2", "", "{"))
+
+	var mu sync.Mutex
+	handled := make(map[string][]string)
+	done := forEachStudy(records, 3, func(m events.SQSMessage) bool {
+		// Give the other studies' goroutines a chance to interleave.
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		study := studyIDOf(m)
+		handled[study] = append(handled[study], m.MessageId)
+		return m.MessageId != "4"
+	})
+
+	want := map[string][]string{
+		"S0": {"0", "3", "6", "9"},
+		"S1": {"1", "4", "7", "10"},
+		"S2": {"2", "5", "8", "11"},
+		"":   {"u1", "u2"},
+	}
+	for study, ids := range want {
+		if !slices.Equal(handled[study], ids) {
+			t.Errorf("study %q handled %v, want %v", study, handled[study], ids)
+		}
+	}
+	for i, m := range records {
+		if done[i] != (m.MessageId != "4") {
+			t.Errorf("done[%d] = %t for message %s", i, done[i], m.MessageId)
+		}
+	}
+}
+
+func TestForEachStudyLimitsConcurrency(t *testing.T) {
+	for _, n := range []int{1, 2, 4} {
+		t.Run(fmt.Sprint("BATCH_CONCURRENCY=", n), func(t *testing.T) {
+			var records []events.SQSMessage
+			for i := range 8 {
+				records = append(records, record(fmt.Sprint(i), fmt.Sprintf("S%d", i), ""))
+			}
+
+			// Each study blocks until released, so the studies in flight
+			// are those started and not yet released. One is released per
+			// study started once n are in flight, which frees a slot for
+			// the next.
+			started, release := make(chan struct{}), make(chan struct{})
+			var mu sync.Mutex
+			inFlight, most := 0, 0
+			finished := make(chan struct{})
+			go func() {
+				defer close(finished)
+				forEachStudy(records, n, func(events.SQSMessage) bool {
+					mu.Lock()
+					inFlight++
+					most = max(most, inFlight)
+					mu.Unlock()
+
+					started <- struct{}{}
+					<-release
+
+					mu.Lock()
+					inFlight--
+					mu.Unlock()
+					return true
+				})
+			}()
+
+			for i := range records {
+				<-started
+				if i == n-1 {
+					// Let any study started past the limit get as far as
+					// counting itself in flight.
+					for range 1000 {
+						runtime.Gosched()
+					}
+				}
+				if i >= n-1 {
+					release <- struct{}{}
+				}
+			}
+			close(release)
+			<-finished
+
+			if most != n {
+				t.Errorf("at most %d studies were in flight, want %d", most, n)
+			}
+		})
+	}
+}
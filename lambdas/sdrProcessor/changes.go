@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// storedNode is a node of a study as it is in the graph before a write.
+type storedNode struct {
+	label      string
+	properties map[string]any
+}
+
+func readStudyNodes(ctx context.Context, tx neo4j.ManagedTransaction, studyID string) (map[string]storedNode, error) {
+	result, err := tx.Run(ctx, graphschema.StudyNodesQuery(), map[string]any{"studyId": studyID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nodes of study %s: %w", studyID, err)
+	}
+	records, err := result.Collect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nodes of study %s: %w", studyID, err)
+	}
+
+	nodes := make(map[string]storedNode, len(records))
+	for _, record := range records {
+		key, _, err := neo4j.GetRecordValue[string](record, "key")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read node key: %w", err)
+		}
+		labels, _, err := neo4j.GetRecordValue[[]any](record, "labels")
+		if err != nil || len(labels) == 0 {
+			return nil, fmt.Errorf("failed to read labels of node %s: %v", key, err)
+		}
+		properties, _, err := neo4j.GetRecordValue[map[string]any](record, "properties")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read properties of node %s: %w", key, err)
+		}
+		nodes[key] = storedNode{label: fmt.Sprint(labels[0]), properties: properties}
+	}
+	return nodes, nil
+}
+
+// summarizeChanges compares the nodes a study has in the graph with those
+// its new document writes. It returns the summary and the keys of the
+// nodes the document no longer has. A node counts as updated when one of
+// the properties the schema copies onto it changed.
+func summarizeChanges(stored map[string]storedNode, entities map[string]graphschema.Entity) (models.ChangeSummary, []string) {
+	summary := models.ChangeSummary{}
+	section := func(label string) *models.SectionChanges {
+		if summary[label] == nil {
+			summary[label] = &models.SectionChanges{}
+		}
+		return summary[label]
+	}
+
+	for key, entity := range entities {
+		node, ok := stored[key]
+		if !ok {
+			section(entity.Label).Added = append(section(entity.Label).Added, entity.ID)
+			continue
+		}
+		for property, value := range entity.Properties {
+			if !sameValue(node.properties[property], value) {
+				section(entity.Label).Updated = append(section(entity.Label).Updated, entity.ID)
+				break
+			}
+		}
+	}
+
+	var removed []string
+	for key, node := range stored {
+		if _, ok := entities[key]; ok {
+			continue
+		}
+		removed = append(removed, key)
+		id, _ := node.properties["id"].(string)
+		section(node.label).Removed = append(section(node.label).Removed, id)
+	}
+
+	for _, changes := range summary {
+		sort.Strings(changes.Added)
+		sort.Strings(changes.Updated)
+		sort.Strings(changes.Removed)
+	}
+	sort.Strings(removed)
+	return summary, removed
+}
+
+// sameValue compares a stored property with a document value. The graph
+// returns whole numbers as int64 where the document has float64, and a
+// property set to null is not stored at all.
+func sameValue(stored, doc any) bool {
+	if stored == nil || doc == nil {
+		return stored == nil && doc == nil
+	}
+	return fmt.Sprint(stored) == fmt.Sprint(doc)
+}
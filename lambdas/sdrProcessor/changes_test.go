@@ -0,0 +1,144 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+// study returns a document of study S1 with one design holding the arms,
+// epochs and encounters given as id: name.
+func study(arms, epochs, encounters map[string]string) map[string]any {
+	list := func(entities map[string]string) []any {
+		var items []any
+		for id, name := range entities {
+			items = append(items, map[string]any{"id": id, "name": name})
+		}
+		return items
+	}
+	return map[string]any{
+		"id": "S1",
+		"versions": []any{map[string]any{
+			"id":                "V1",
+			"versionIdentifier": "1",
+			"studyDesigns": []any{map[string]any{
+				"id":         "D1",
+				"arms":       list(arms),
+				"epochs":     list(epochs),
+				"encounters": list(encounters),
+			}},
+		}},
+	}
+}
+
+// stored returns the nodes an earlier write of doc left in the graph.
+func stored(doc map[string]any) map[string]storedNode {
+	nodes := make(map[string]storedNode)
+	for key, entity := range graphschema.USDM.Entities(doc) {
+		properties := map[string]any{"id": entity.ID, graphschema.KeyProperty: key, graphschema.StudyProperty: "S1"}
+		for p, v := range entity.Properties {
+			if v != nil {
+				properties[p] = v
+			}
+		}
+		nodes[key] = storedNode{label: entity.Label, properties: properties}
+	}
+	return nodes
+}
+
+func TestSummarizeChanges(t *testing.T) {
+	before := study(
+		map[string]string{"A1": "Placebo", "A2": "Drug"},
+		map[string]string{"E1": "Screening", "E2": "Treatment"},
+		map[string]string{"N1": "Visit 1"},
+	)
+	after := study(
+		map[string]string{"A1": "Placebo"},
+		map[string]string{"E1": "Screening", "E2": "Treatment period"},
+		map[string]string{"N1": "Visit 1", "N2": "Visit 2", "N3": "Visit 3"},
+	)
+
+	summary, removed := summarizeChanges(stored(before), graphschema.USDM.Entities(after))
+
+	want := models.ChangeSummary{
+		graphschema.Arm:       {Removed: []string{"A2"}},
+		graphschema.Epoch:     {Updated: []string{"E2"}},
+		graphschema.Encounter: {Added: []string{"N2", "N3"}},
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("summary:\n got %s\nwant %s", describe(summary), describe(want))
+	}
+	if wantRemoved := []string{graphschema.USDM.Key(graphschema.Arm, "S1", "A2")}; !slices.Equal(removed, wantRemoved) {
+		t.Errorf("removed = %v, want %v", removed, wantRemoved)
+	}
+
+	summary, removed = summarizeChanges(stored(after), graphschema.USDM.Entities(after))
+	if len(summary) != 0 || len(removed) != 0 {
+		t.Errorf("resubmitting the same study gave %s, removed %v", describe(summary), removed)
+	}
+
+	summary, removed = summarizeChanges(nil, graphschema.USDM.Entities(after))
+	if len(removed) != 0 || len(summary[graphschema.Study].Added) != 1 || len(summary[graphschema.Encounter].Added) != 3 {
+		t.Errorf("a first write gave %s, removed %v, want everything added", describe(summary), removed)
+	}
+}
+
+func describe(summary models.ChangeSummary) string {
+	var parts []string
+	for label, c := range summary {
+		parts = append(parts, label+"{added "+strings.Join(c.Added, ",")+" updated "+strings.Join(c.Updated, ",")+" removed "+strings.Join(c.Removed, ",")+"}")
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, " ")
+}
+
+func TestSameValue(t *testing.T) {
+	tests := []struct {
+		stored, doc any
+		want        bool
+	}{
+		{int64(3), 3.0, true},
+		{"a", "a", true},
+		{nil, nil, true},
+		{nil, "", false},
+		{"", nil, false},
+		{int64(3), 3.5, false},
+		{[]any{"x", "y"}, []any{"x", "y"}, true},
+	}
+	for _, tt := range tests {
+		if got := sameValue(tt.stored, tt.doc); got != tt.want {
+			t.Errorf("sameValue(%#v, %#v) = %t, want %t", tt.stored, tt.doc, got, tt.want)
+		}
+	}
+}
+
+// TestPruneQueriesTakeRemoved checks the prune statements against the
+// parameters SaveStudyToGraph runs them with: the edges dropped are those
+// within $studyId, and only nodes of the study keyed in $removed, which
+// summarizeChanges computes, are deleted.
+func TestPruneQueriesTakeRemoved(t *testing.T) {
+	queries := graphschema.PruneQueries()
+	if len(queries) != 2 {
+		t.Fatalf("got %d prune statements, want 2", len(queries))
+	}
+	edges, nodes := queries[0], queries[1]
+
+	within := "n." + graphschema.StudyProperty + " = $studyId AND m." + graphschema.StudyProperty + " = $studyId"
+	if !strings.Contains(edges, within) || !strings.Contains(edges, "DELETE r") {
+		t.Errorf("edge prune does not drop only edges within the study:\n%s", edges)
+	}
+	for _, want := range []string{
+		"n." + graphschema.StudyProperty + " = $studyId",
+		"n." + graphschema.KeyProperty + " IN $removed",
+		"WHERE referrers = 0",
+		"DETACH DELETE n",
+	} {
+		if !strings.Contains(nodes, want) {
+			t.Errorf("node prune lacks %q:\n%s", want, nodes)
+		}
+	}
+}
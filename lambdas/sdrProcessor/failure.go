@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/dlq"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// failure is why a message could not be processed. A transient failure
+// may succeed if the message is received again; a permanent one never
+// will.
+type failure struct {
+	stage     dlq.Stage
+	code      dlq.Code
+	transient bool
+	err       error
+}
+
+func (f *failure) Error() string { return fmt.Sprintf("%s failed: %v", f.stage, f.err) }
+
+func (f *failure) Unwrap() error { return f.err }
+
+func permanent(stage dlq.Stage, code dlq.Code, err error) *failure {
+	return &failure{stage: stage, code: code, err: err}
+}
+
+func transient(stage dlq.Stage, code dlq.Code, err error) *failure {
+	return &failure{stage: stage, code: code, transient: true, err: err}
+}
+
+// transientNeptuneErrors are the Neptune exceptions that are worth
+// retrying: a concurrent write to the same nodes, a failover in progress
+// or an overloaded cluster.
+var transientNeptuneErrors = []string{
+	"ConcurrentModificationException",
+	"ReadOnlyViolationException",
+	"ThrottlingException",
+	"TooManyRequestsException",
+	"MemoryLimitExceededException",
+}
+
+// isTransientGraphError reports whether a failed graph write may succeed
+// if it is tried again: first by retryTransient, then by SQS delivering the
+// message again. Neptune reports its exceptions by name in the
+// message of a Bolt failure rather than with Neo4j's transient codes, so
+// both are checked.
+func isTransientGraphError(err error) bool {
+	var connectivity *neo4j.ConnectivityError
+	var limit *neo4j.TransactionExecutionLimit
+	if neo4j.IsRetryable(err) || errors.As(err, &connectivity) || errors.As(err, &limit) {
+		return true
+	}
+	message := err.Error()
+	for _, name := range transientNeptuneErrors {
+		if strings.Contains(message, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// The bounds of retryTransient. They are variables so tests can shorten
+// the backoff.
+var (
+	writeAttempts = 4
+	backoffBase   = 200 * time.Millisecond
+	backoffCap    = 3 * time.Second
+)
+
+// retryTransient calls write until it succeeds, fails with an error that
+// is not transient or has been tried writeAttempts times. Between attempts
+// it sleeps for a random duration of up to an exponentially growing
+// backoff, so that writers conflicting on the same nodes spread out. It
+// gives up early, with the last error, if ctx is done.
+func retryTransient(ctx context.Context, write func() error) error {
+	backoff := backoffBase
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err == nil || !isTransientGraphError(err) || attempt == writeAttempts {
+			return err
+		}
+
+		delay := rand.N(backoff)
+		log.Printf("Transient graph error on attempt %d of %d, retrying in %s: %v", attempt, writeAttempts, delay, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		backoff = min(2*backoff, backoffCap)
+	}
+}
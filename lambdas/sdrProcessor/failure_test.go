@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/dlq"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+var errConflict = errors.New("Neo4jError: ConcurrentModificationException: operation failed due to conflicting concurrent operations")
+
+// failingSave returns a saveStudy that fails with err the first n calls
+// and succeeds after, and the number of calls it has had.
+func failingSave(n int, err error) (func(context.Context, models.Study, time.Time) (*Ingestion, error), *int) {
+	calls := 0
+	return func(context.Context, models.Study, time.Time) (*Ingestion, error) {
+		calls++
+		if calls <= n {
+			return nil, err
+		}
+		return &Ingestion{Revision: 1}, nil
+	}, &calls
+}
+
+func TestProcessRetriesTransientWrites(t *testing.T) {
+	defer func(save func(context.Context, models.Study, time.Time) (*Ingestion, error), base time.Duration) {
+		saveStudy, backoffBase = save, base
+	}(saveStudy, backoffBase)
+	backoffBase = time.Millisecond
+
+	message := events.SQSMessage{
+		MessageId: "m-1",
+		Body:      `{"usdmVersion":"3.0.0","study":{"id":"S1","versions":[{"id":"V1","versionIdentifier":"1"}]}}`,
+	}
+
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		wantCalls int
+		wantCode  dlq.Code // empty for success
+		transient bool
+	}{
+		{"succeeds first time", 0, errConflict, 1, "", false},
+		{"succeeds after transient failures", writeAttempts - 1, errConflict, writeAttempts, "", false},
+		{"transient until the retry runs out", writeAttempts, errConflict, writeAttempts, dlq.GraphUnavailable, true},
+		{"permanent failure is not retried", 1, errors.New("Neo4jError: MalformedQueryException"), 1, dlq.GraphWriteFailed, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls *int
+			saveStudy, calls = failingSave(tt.failures, tt.err)
+
+			_, ingestion, err := process(context.Background(), message)
+			if *calls != tt.wantCalls {
+				t.Errorf("saveStudy called %d times, want %d", *calls, tt.wantCalls)
+			}
+			if tt.wantCode == "" {
+				if err != nil || ingestion == nil {
+					t.Fatalf("process = %v, %v, want the ingestion", ingestion, err)
+				}
+				return
+			}
+			var f *failure
+			if !errors.As(err, &f) {
+				t.Fatalf("process error = %v, want a failure", err)
+			}
+			if f.code != tt.wantCode || f.transient != tt.transient {
+				t.Errorf("failure = %s transient %t, want %s transient %t", f.code, f.transient, tt.wantCode, tt.transient)
+			}
+		})
+	}
+}
+
+func TestRetryTransientStopsWhenContextIsDone(t *testing.T) {
+	defer func(base, limit time.Duration) { backoffBase, backoffCap = base, limit }(backoffBase, backoffCap)
+	backoffBase, backoffCap = time.Hour, time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := retryTransient(ctx, func() error {
+		calls++
+		cancel()
+		return errConflict
+	})
+	if !errors.Is(err, errConflict) || calls != 1 {
+		t.Errorf("retryTransient = %v after %d calls, want the conflict after 1", err, calls)
+	}
+}
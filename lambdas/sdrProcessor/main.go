@@ -3,47 +3,95 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/ankit-lilly/dtd-go-backend/internal/claimcheck"
+	"github.com/ankit-lilly/dtd-go-backend/internal/dlq"
+	"github.com/ankit-lilly/dtd-go-backend/internal/notify"
+	"github.com/ankit-lilly/dtd-go-backend/internal/studyevent"
+	"github.com/ankit-lilly/dtd-go-backend/internal/submission"
+	"github.com/ankit-lilly/dtd-go-backend/internal/usdm"
 	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+const (
+	// maxReceiveCountEnv is the environment variable holding the queue's
+	// redrive maxReceiveCount.
+	maxReceiveCountEnv = "MAX_RECEIVE_COUNT"
+
+	// concurrencyEnv is the environment variable holding how many studies
+	// of a batch are processed at once.
+	concurrencyEnv = "BATCH_CONCURRENCY"
 )
 
 type UsdmPayload struct {
-	Study         models.Study `json:"study"`
-	UsdmVersion   string       `json:"usdmVersion"`
-	SystemName    string       `json:"systemName"`
-	SystemVersion string       `json:"systemVersion"`
+	Study         json.RawMessage `json:"study"`
+	UsdmVersion   string          `json:"usdmVersion"`
+	SystemName    string          `json:"systemName"`
+	SystemVersion string          `json:"systemVersion"`
 }
 
+// parseStudyData decodes the payload with the adapter registered for its
+// usdmVersion; see internal/usdm.
 func parseStudyData(data string) (models.Study, error) {
 	var payload UsdmPayload
 	err := json.Unmarshal([]byte(data), &payload)
 	if err != nil {
-		return models.Study{}, fmt.Errorf("failed to parse usdm payload: %w", err)
+		return models.Study{}, permanent(dlq.Parse, dlq.MalformedPayload, fmt.Errorf("failed to parse usdm payload: %w", err))
 	}
-	return payload.Study, nil
+
+	study, err := usdm.Decode(payload.UsdmVersion, payload.Study)
+	if err != nil {
+		return models.Study{}, permanent(dlq.Parse, dlq.InvalidPayload, fmt.Errorf("failed to parse usdm payload: %w", err))
+	}
+	return study, nil
 }
 
-func handler(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
-	var failedMessages []events.SQSBatchItemFailure
-	for _, message := range event.Records {
-		log.Printf("Processing message ID: %s", message.MessageId)
+var (
+	// statusStore records each submission's progress; see internal/submission.
+	statusStore submission.Store
 
-		study, err := parseStudyData(message.Body)
-		if err != nil {
-			log.Printf("Error parsing study data: %v", err)
-			failedMessages = append(failedMessages, events.SQSBatchItemFailure{
-				ItemIdentifier: message.MessageId,
-			})
-			continue
-		}
+	// payloads reads the payloads sent by reference; see internal/claimcheck.
+	payloads *claimcheck.Store
 
-		if err := SaveStudyToGraph(ctx, study); err != nil {
-			log.Printf("Error processing study %s: %v", study.ID, err)
+	// deadLetters receives the messages that are given up on, with an
+	// error envelope; see internal/dlq. maxReceiveCount is the queue's
+	// redrive setting, after which a transient failure is given up on too.
+	deadLetters     dlq.Queue
+	maxReceiveCount int
+
+	// publisher announces the studies written; see internal/studyevent.
+	publisher studyevent.Publisher
+
+	// notifier runs the mutations GraphQL subscriptions listen to; see
+	// internal/notify. statusStore notifies through it too.
+	notifier notify.Notifier
+
+	// concurrency is how many studies of a batch are processed at once.
+	concurrency = 1
+
+	// saveStudy writes a study to the graph; tests replace it.
+	saveStudy = SaveStudyToGraph
+)
+
+func handler(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	done := forEachStudy(event.Records, concurrency, func(message events.SQSMessage) bool {
+		return handleMessage(ctx, message)
+	})
+
+	var failedMessages []events.SQSBatchItemFailure
+	for i, message := range event.Records {
+		if !done[i] {
 			failedMessages = append(failedMessages, events.SQSBatchItemFailure{
 				ItemIdentifier: message.MessageId,
 			})
@@ -54,6 +102,269 @@ func handler(ctx context.Context, event events.SQSEvent) (events.SQSEventRespons
 	}, nil
 }
 
+// forEachStudy calls handle on every record and returns what it reported
+// for each. Each study's messages are handled in batch order by one
+// goroutine, at most n studies at a time. Every goroutine only sets the
+// entries of done for its own messages.
+func forEachStudy(records []events.SQSMessage, n int, handle func(events.SQSMessage) bool) []bool {
+	done := make([]bool, len(records))
+	slots := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for _, indexes := range groupByStudy(records) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			for _, i := range indexes {
+				done[i] = handle(records[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return done
+}
+
+// groupByStudy returns the indexes of the records of each study, in batch
+// order. Messages whose study cannot be told without reading their payload
+// are grouped together, so they are still handled one at a time.
+func groupByStudy(records []events.SQSMessage) [][]int {
+	var groups [][]int
+	group := make(map[string]int)
+	for i, message := range records {
+		studyID := studyIDOf(message)
+		g, ok := group[studyID]
+		if !ok {
+			g = len(groups)
+			group[studyID] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// handleMessage processes one message and reports whether it is done
+// with, or must be delivered again.
+func handleMessage(ctx context.Context, message events.SQSMessage) bool {
+	log.Printf("Processing message ID: %s", message.MessageId)
+
+	submissionID := submissionIDOf(message)
+	track(submissionID, func(id string) error { return statusStore.MarkProcessing(ctx, id) })
+
+	study, ingestion, err := process(ctx, message)
+	var stale *StaleSubmissionError
+	if errors.As(err, &stale) {
+		log.Printf("Rejected message %s: %v", message.MessageId, stale)
+		track(submissionID, func(id string) error { return statusStore.MarkRejected(ctx, id, stale) })
+		return true
+	}
+	if err != nil {
+		log.Printf("Error processing message %s for study %s: %v", message.MessageId, study.ID, err)
+		// A message SQS will deliver again is still being processed; the
+		// submission only fails once it is given up on.
+		if !deadLetter(ctx, message, submissionID, study.ID, err) {
+			return false
+		}
+		track(submissionID, func(id string) error { return statusStore.MarkFailed(ctx, id, err) })
+		return true
+	}
+
+	for section, c := range ingestion.Changes {
+		log.Printf("Study %s %s: %d added, %d updated, %d removed", study.ID, section, len(c.Added), len(c.Updated), len(c.Removed))
+	}
+	track(submissionID, func(id string) error {
+		return statusStore.MarkSucceeded(ctx, id, ingestion.Revision, ingestion.Changes)
+	})
+	announce(ctx, studyevent.Ingested(study, submissionID, ingestion.Revision, ingestion.Changes))
+	return true
+}
+
+// process writes the study a message carries. Its error is a
+// *StaleSubmissionError if the graph holds a newer submission of the study,
+// and a *failure otherwise; the study is returned as far as it was read.
+func process(ctx context.Context, message events.SQSMessage) (models.Study, *Ingestion, error) {
+	body, err := payloads.Resolve(ctx, message)
+	if errors.Is(err, claimcheck.ErrNotFound) {
+		return models.Study{}, nil, permanent(dlq.Resolve, dlq.PayloadUnavailable, err)
+	}
+	if err != nil {
+		return models.Study{}, nil, transient(dlq.Resolve, dlq.PayloadUnavailable, err)
+	}
+
+	study, err := parseStudyData(body)
+	if err != nil {
+		return study, nil, err
+	}
+
+	// sdrHandler validated the payload already, but a message can also
+	// come back from the dead letter queue edited.
+	violations, err := usdm.Validate([]byte(body))
+	if err != nil {
+		return study, nil, permanent(dlq.Validate, dlq.MalformedPayload, err)
+	}
+	if len(violations) > 0 {
+		v := violations[0]
+		return study, nil, permanent(dlq.Validate, dlq.ValidationFailed,
+			fmt.Errorf("%d USDM violations, first at %q: %s", len(violations), v.Pointer, v.Message))
+	}
+
+	// A transient error is only reported once the retry has run out.
+	var ingestion *Ingestion
+	err = retryTransient(ctx, func() error {
+		ingestion, err = saveStudy(ctx, study, submittedAtOf(message))
+		return err
+	})
+	var stale *StaleSubmissionError
+	if errors.As(err, &stale) {
+		return study, nil, stale
+	}
+	if err != nil {
+		if isTransientGraphError(err) {
+			return study, nil, transient(dlq.Write, dlq.GraphUnavailable, err)
+		}
+		return study, nil, permanent(dlq.Write, dlq.GraphWriteFailed, err)
+	}
+	return study, ingestion, nil
+}
+
+// deadLetter sends a failed message to the dead letter queue, with its
+// error envelope, and reports whether it did. A permanent failure goes
+// there at once. A transient one is left to SQS to deliver again until
+// this is the last receive the redrive policy allows.
+func deadLetter(ctx context.Context, message events.SQSMessage, submissionID, studyID string, err error) bool {
+	var f *failure
+	if !errors.As(err, &f) || deadLetters == nil {
+		return false
+	}
+
+	receives, _ := strconv.Atoi(message.Attributes["ApproximateReceiveCount"])
+	if f.transient && receives < maxReceiveCount {
+		return false
+	}
+
+	envelope := dlq.Envelope{
+		MessageID:    message.MessageId,
+		SubmissionID: submissionID,
+		StudyID:      studyID,
+		Stage:        f.stage,
+		Code:         f.code,
+		Error:        f.err.Error(),
+		Transient:    f.transient,
+		ReceiveCount: receives,
+		Timestamp:    time.Now().UTC(),
+	}
+	dead, sendErr := dlq.FromEvent(message).WithEnvelope(envelope)
+	if sendErr == nil {
+		_, sendErr = deadLetters.Send(ctx, dead)
+	}
+	if sendErr != nil {
+		log.Printf("Failed to dead-letter message %s, leaving it to the redrive policy: %v", message.MessageId, sendErr)
+		return false
+	}
+	log.Printf("Moved message %s to the dead letter queue: %s at %s", message.MessageId, f.code, f.stage)
+	return true
+}
+
+func submissionIDOf(message events.SQSMessage) string {
+	attr, ok := message.MessageAttributes[submission.MessageAttribute]
+	if !ok || attr.StringValue == nil {
+		return ""
+	}
+	return *attr.StringValue
+}
+
+// studyIDOf returns the id of the study a message carries, from its
+// attribute or else its body; see dlq.Message.StudyID.
+func studyIDOf(message events.SQSMessage) string {
+	attr, ok := message.MessageAttributes[submission.StudyIDAttribute]
+	if ok && attr.StringValue != nil {
+		return *attr.StringValue
+	}
+	return dlq.FromEvent(message).StudyID()
+}
+
+// submittedAtOf returns when the message's submission was accepted, or
+// zero for a message sent before submissions carried it.
+func submittedAtOf(message events.SQSMessage) time.Time {
+	attr, ok := message.MessageAttributes[submission.SubmittedAtAttribute]
+	if !ok || attr.StringValue == nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, *attr.StringValue)
+	if err != nil {
+		log.Printf("Ignoring %s %q of message %s: %v", submission.SubmittedAtAttribute, *attr.StringValue, message.MessageId, err)
+		return time.Time{}
+	}
+	return t
+}
+
+// announce publishes a study event, if there is a topic to send it to,
+// and notifies the GraphQL subscribers of the study, if there is an API
+// to notify. The study is written by then, so a failure is logged rather
+// than having the message processed again.
+func announce(ctx context.Context, event studyevent.Event) {
+	if publisher != nil {
+		if err := publisher.Publish(ctx, event); err != nil {
+			log.Printf("Failed to publish %s event of study %s: %v", event.Type, event.StudyID, err)
+		}
+	}
+	if notifier != nil {
+		if err := notifier.StudyIngested(ctx, notify.IngestionOf(event)); err != nil {
+			log.Printf("Failed to notify subscribers of study %s: %v", event.StudyID, err)
+		}
+	}
+}
+
+// track applies a status change to the submission, if the message carried
+// one. A status store outage is logged rather than failing the study write.
+func track(submissionID string, mark func(id string) error) {
+	if submissionID == "" {
+		return
+	}
+	if err := mark(submissionID); err != nil {
+		log.Printf("Failed to update status of submission %s: %v", submissionID, err)
+	}
+}
+
 func main() {
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		log.Fatalf("unable to load SDK config, %v", err)
+	}
+
+	tableName := os.Getenv(submission.TableEnv)
+	if tableName == "" {
+		log.Fatalf("%s environment variable is not set.", submission.TableEnv)
+	}
+	statusStore = submission.NewDynamoStore(cfg, tableName)
+	payloads = claimcheck.NewStore(cfg, "")
+
+	// Without a dead letter queue URL, every failure is left to the
+	// queue's redrive policy, as before.
+	if url := os.Getenv(dlq.URLEnv); url != "" {
+		deadLetters = dlq.NewSQSQueue(cfg, url)
+		maxReceiveCount, err = strconv.Atoi(os.Getenv(maxReceiveCountEnv))
+		if err != nil {
+			log.Fatalf("%s must be set to the queue's maxReceiveCount: %v", maxReceiveCountEnv, err)
+		}
+	}
+
+	if arn := os.Getenv(studyevent.TopicEnv); arn != "" {
+		publisher = studyevent.NewSNSPublisher(cfg, arn)
+	}
+	if url := os.Getenv(notify.GraphQLURLEnv); url != "" {
+		notifier = notify.NewAppSyncNotifier(cfg, url)
+		statusStore = notify.NewStatusStore(statusStore, notifier)
+	}
+
+	// Without it, a batch is processed one message at a time, as before.
+	if n := os.Getenv(concurrencyEnv); n != "" {
+		concurrency, err = strconv.Atoi(n)
+		if err != nil || concurrency < 1 {
+			log.Fatalf("%s must be a positive number: %q", concurrencyEnv, n)
+		}
+	}
+
 	lambda.Start(handler)
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/dlq"
+	"github.com/ankit-lilly/dtd-go-backend/internal/submission"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// submitted returns a message of submission sub-1 with the given body and
+// ApproximateReceiveCount, and a store tracking the submission.
+func submitted(t *testing.T, body, receives string) (events.SQSMessage, *submission.MemoryStore) {
+	t.Helper()
+	store := submission.NewMemoryStore()
+	if err := store.Create(context.Background(), submission.Record{ID: "sub-1"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	id := "sub-1"
+	return events.SQSMessage{
+		MessageId:  "m-1",
+		Body:       body,
+		Attributes: map[string]string{"ApproximateReceiveCount": receives},
+		MessageAttributes: map[string]events.SQSMessageAttribute{
+			submission.MessageAttribute: {StringValue: &id, DataType: "String"},
+		},
+	}, store
+}
+
+func TestHandleMessageMarksFailedOnlyWhenDeadLettered(t *testing.T) {
+	ctx := context.Background()
+	defer func(s submission.Store, q dlq.Queue) { statusStore, deadLetters = s, q }(statusStore, deadLetters)
+
+	t.Run("dead-lettered", func(t *testing.T) {
+		message, store := submitted(t, "not json", "1")
+		queue := dlq.NewMemoryQueue()
+		statusStore, deadLetters = store, queue
+
+		if !handleMessage(ctx, message) {
+			t.Error("a dead-lettered message was left for SQS to deliver again")
+		}
+		if n := len(queue.Messages()); n != 1 {
+			t.Errorf("dead letter queue holds %d messages, want 1", n)
+		}
+		if r, _ := store.Get(ctx, "sub-1"); r.Status != submission.Failed || r.Error == "" {
+			t.Errorf("submission = %s (%q), want failed with the error", r.Status, r.Error)
+		}
+	})
+
+	t.Run("left to the redrive policy", func(t *testing.T) {
+		message, store := submitted(t, "not json", "1")
+		statusStore, deadLetters = store, nil
+
+		if handleMessage(ctx, message) {
+			t.Error("a message that was not dead-lettered was reported done")
+		}
+		if r, _ := store.Get(ctx, "sub-1"); r.Status != submission.Processing || r.Error != "" {
+			t.Errorf("submission = %s (%q), want processing", r.Status, r.Error)
+		}
+	})
+}
+
+func TestDeadLetterWaitsForLastReceive(t *testing.T) {
+	ctx := context.Background()
+	defer func(q dlq.Queue, n int) { deadLetters, maxReceiveCount = q, n }(deadLetters, maxReceiveCount)
+	maxReceiveCount = 3
+	outage := transient(dlq.Write, dlq.GraphUnavailable, errors.New("ConcurrentModificationException"))
+
+	tests := []struct {
+		name     string
+		err      error
+		receives string
+		want     bool
+	}{
+		{"transient before the last receive", outage, "2", false},
+		{"transient on the last receive", outage, "3", true},
+		{"permanent on the first receive", permanent(dlq.Parse, dlq.MalformedPayload, errors.New("bad")), "1", true},
+		{"not a failure", errors.New("unclassified"), "3", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := dlq.NewMemoryQueue()
+			deadLetters = queue
+			message := events.SQSMessage{MessageId: "m-1", Body: "{}", Attributes: map[string]string{"ApproximateReceiveCount": tt.receives}}
+
+			if got := deadLetter(ctx, message, "sub-1", "S1", tt.err); got != tt.want {
+				t.Errorf("deadLetter = %t, want %t", got, tt.want)
+			}
+			if n := len(queue.Messages()); (n == 1) != tt.want {
+				t.Errorf("dead letter queue holds %d messages", n)
+			}
+		})
+	}
+}
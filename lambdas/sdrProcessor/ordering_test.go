@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ankit-lilly/dtd-go-backend/internal/graphschema"
+	"github.com/ankit-lilly/dtd-go-backend/pkg/models"
+)
+
+func TestCompareVersionIdentifiers(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.10", "1.9", 1},
+		{"1.9", "1.10", -1},
+		{"2", "10", -1},
+		{"1.0", "1.0", 0},
+		{"01", "1", 0},
+		{"1.0", "1.0.1", -1},
+		{"1.0-rc1", "1.0-rc2", -1},
+		{"1.0-rc10", "1.0-rc9", 1},
+		{"1.0", "1.0-rc1", -1},
+		{"alpha", "beta", -1},
+		{"v2", "v10", -1},
+		{"v2", "2", 1}, // 'v' sorts after the digit
+		{"", "1", -1},
+		{"", "", 0},
+		{"99999999999999999999999", "1", 1}, // too long for uint64
+	}
+	for _, tt := range tests {
+		if got := compareVersionIdentifiers(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersionIdentifiers(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCheckOrder(t *testing.T) {
+	submitted := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	study := func(versions ...string) models.Study {
+		s := models.Study{ID: "S1"}
+		for _, v := range versions {
+			s.Versions = append(s.Versions, &models.StudyVersion{VersionIdentifier: v})
+		}
+		return s
+	}
+	// stored returns the nodes of S1 as written by a submission accepted
+	// at storedAt, zero for one that did not say, with the given versions.
+	stored := func(storedAt time.Time, versions ...string) map[string]storedNode {
+		root := map[string]any{"id": "S1"}
+		if !storedAt.IsZero() {
+			root[graphschema.SubmittedAtProperty] = graphschema.FormatIngestedAt(storedAt)
+		}
+		nodes := map[string]storedNode{"S1": {label: graphschema.Study, properties: root}}
+		for _, v := range versions {
+			nodes["S1/"+v] = storedNode{label: graphschema.StudyVersion, properties: map[string]any{"versionIdentifier": v}}
+		}
+		return nodes
+	}
+
+	tests := []struct {
+		name        string
+		study       models.Study
+		submittedAt time.Time
+		stored      map[string]storedNode
+		wantStale   bool
+	}{
+		{"first write", study("1"), submitted, nil, false},
+		{"newer version", study("1.10"), submitted, stored(time.Time{}, "1.9"), false},
+		{"older version", study("1.9"), submitted, stored(time.Time{}, "1.10"), true},
+		{"older than the latest stored version", study("2"), submitted, stored(time.Time{}, "1", "3"), true},
+		{"latest incoming version counts", study("1", "4"), submitted, stored(time.Time{}, "3"), false},
+		{"incoming without identifier", study(""), submitted, stored(time.Time{}, "3"), false},
+		{"stored without identifier", study("1"), submitted, stored(time.Time{}), false},
+		{"equal versions, later submission", study("2"), submitted, stored(submitted.Add(-time.Minute), "2"), false},
+		{"equal versions, earlier submission", study("2"), submitted, stored(submitted.Add(time.Minute), "2"), true},
+		{"equal versions, same submission", study("2"), submitted, stored(submitted, "2"), false},
+		{"incoming without submittedAt", study("2"), time.Time{}, stored(submitted, "2"), false},
+		{"stored without submittedAt", study("2"), submitted, stored(time.Time{}, "2"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkOrder(tt.study, tt.submittedAt, tt.stored)
+			var stale *StaleSubmissionError
+			if got := errors.As(err, &stale); got != tt.wantStale {
+				t.Fatalf("checkOrder = %v, want stale %t", err, tt.wantStale)
+			}
+			if stale != nil && stale.StudyID != "S1" {
+				t.Errorf("stale study = %q, want S1", stale.StudyID)
+			}
+		})
+	}
+}
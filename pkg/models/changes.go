@@ -0,0 +1,52 @@
+package models
+
+// SectionChanges lists, by document id, the entities of one section of a
+// study that an ingestion added, changed a property of, or removed.
+type SectionChanges struct {
+	Added   []string `json:"added,omitempty" dynamodbav:"added,omitempty"`
+	Updated []string `json:"updated,omitempty" dynamodbav:"updated,omitempty"`
+	Removed []string `json:"removed,omitempty" dynamodbav:"removed,omitempty"`
+}
+
+// ChangeSummary is the outcome of ingesting a study, keyed by section,
+// i.e. the node label such as "Arm" or "Activity". Sections without
+// changes are left out.
+type ChangeSummary map[string]*SectionChanges
+
+// StudyVersionDiff is what changed from the Left study version to the
+// Right one, section by section.
+type StudyVersionDiff struct {
+	Left     string         `json:"left"`
+	Right    string         `json:"right"`
+	Sections []*SectionDiff `json:"sections"`
+}
+
+// SectionDiff lists the entities of a section, e.g. "arms", only the right
+// version has, only the left one has, and those both have with different
+// properties.
+type SectionDiff struct {
+	Section string          `json:"section"`
+	Added   []*DiffEntity   `json:"added"`
+	Removed []*DiffEntity   `json:"removed"`
+	Changed []*EntityChange `json:"changed"`
+}
+
+type DiffEntity struct {
+	ID           string  `json:"id"`
+	Name         *string `json:"name,omitempty"`
+	InstanceType string  `json:"instanceType,omitempty"`
+}
+
+type EntityChange struct {
+	DiffEntity
+	Properties []*PropertyChange `json:"properties"`
+}
+
+// PropertyChange is a property of an entity before and after. Nested
+// properties are named by their path, e.g. "type.decode"; values are
+// rendered as strings and nil where the property is absent.
+type PropertyChange struct {
+	Property string  `json:"property"`
+	Before   *string `json:"before"`
+	After    *string `json:"after"`
+}
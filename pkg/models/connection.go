@@ -0,0 +1,26 @@
+package models
+
+// PageInfo follows the Relay cursor connections specification.
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+type Edge[T any] struct {
+	Cursor string `json:"cursor"`
+	Node   *T     `json:"node"`
+}
+
+// Connection is one page of a list query. TotalCount counts every node
+// matching the filter, not just those on the page.
+type Connection[T any] struct {
+	Edges      []*Edge[T] `json:"edges"`
+	PageInfo   PageInfo   `json:"pageInfo"`
+	TotalCount int        `json:"totalCount"`
+}
+
+type StudyConnection = Connection[Study]
+type ActivityConnection = Connection[Activity]
+type EncounterConnection = Connection[Encounter]
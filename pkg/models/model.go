@@ -7,101 +7,313 @@ type Study struct {
 	Label        *string                    `json:"label,omitempty"`
 	Versions     []*StudyVersion            `json:"versions,omitempty"`
 	DocumentedBy []*StudyDefinitionDocument `json:"documentedBy,omitempty"`
+	InstanceType string                     `json:"instanceType,omitempty"`
+	Revision     *int                       `json:"revision,omitempty"`
+	IngestedAt   *string                    `json:"ingestedAt,omitempty"`
 }
 
 type NodeCount struct {
-    Label string `json:"label"`
-    Count int64  `json:"count"` 
+	Label string `json:"label"`
+	Count int64  `json:"count"`
 }
 
 type StudyVersion struct {
-	ID                string               `json:"id"`
-	VersionIdentifier string               `json:"versionIdentifier"`
-	Rationale         *string              `json:"rationale,omitempty"`
-	Study             *Study               `json:"study,omitempty"`
-	StudyDesigns      []*StudyDesign       `json:"studyDesigns,omitempty"`
-	Amendments        []*StudyAmendment    `json:"amendments,omitempty"`
-	Interventions     []*StudyIntervention `json:"interventions,omitempty"`
-	Organizations     []*Organization      `json:"organizations,omitempty"`
-	Roles 					 []*string              `json:"roles,omitempty"`
-	BioMedicalConcepts []*BioMedicalConcept `json:"bioMedicalConcepts,omitempty"`
-	BCSurrogates      []*BCSurrogate       `json:"bcSurrogates,omitempty"`
-	Conditions        []*Conditions        `json:"conditions,omitempty"`
+	ID                        string                      `json:"id"`
+	VersionIdentifier         string                      `json:"versionIdentifier"`
+	Rationale                 *string                     `json:"rationale,omitempty"`
+	DocumentVersionID         *string                     `json:"documentVersionId,omitempty"`
+	Study                     *Study                      `json:"study,omitempty"`
+	Titles                    []*StudyTitle               `json:"titles,omitempty"`
+	StudyIdentifiers          []*StudyIdentifier          `json:"studyIdentifiers,omitempty"`
+	ReferenceIdentifiers      []*ReferenceIdentifier      `json:"referenceIdentifiers,omitempty"`
+	DateValues                []*GovernanceDate           `json:"dateValues,omitempty"`
+	BusinessTherapeuticAreas  []*Code                     `json:"businessTherapeuticAreas,omitempty"`
+	StudyDesigns              []*StudyDesign              `json:"studyDesigns,omitempty"`
+	Amendments                []*StudyAmendment           `json:"amendments,omitempty"`
+	Interventions             []*StudyIntervention        `json:"studyInterventions,omitempty"`
+	AdministrableProducts     []*AdministrableProduct     `json:"administrableProducts,omitempty"`
+	Organizations             []*Organization             `json:"organizations,omitempty"`
+	Roles                     []*StudyRole                `json:"roles,omitempty"`
+	BioMedicalConcepts        []*BioMedicalConcept        `json:"biomedicalConcepts,omitempty"`
+	BCCategories              []*BCCategory               `json:"bcCategories,omitempty"`
+	BCSurrogates              []*BCSurrogate              `json:"bcSurrogates,omitempty"`
+	Conditions                []*Conditions               `json:"conditions,omitempty"`
+	EligibilityCriterionItems []*EligibilityCriterionItem `json:"eligibilityCriterionItems,omitempty"`
+	NarrativeContentItems     []*NarrativeContentItem     `json:"narrativeContentItems,omitempty"`
+	Abbreviations             []*Abbreviation             `json:"abbreviations,omitempty"`
+	InstanceType              string                      `json:"instanceType,omitempty"`
+}
+
+type StudyTitle struct {
+	ID           string `json:"id"`
+	Text         string `json:"text"`
+	Type         *Code  `json:"type,omitempty"`
+	InstanceType string `json:"instanceType"`
+}
+
+type StudyIdentifier struct {
+	ID           string  `json:"id"`
+	Text         string  `json:"text"`
+	ScopeID      *string `json:"scopeId,omitempty"`
+	InstanceType string  `json:"instanceType"`
 }
 
-type Administration struct {
-	ID           string    `json:"id"`
-	Name         *string   `json:"name,omitempty"`
-	Label        *string   `json:"label,omitempty"`
-	Description  *string   `json:"description,omitempty"`
-	Duration     *Quantity `json:"duration,omitempty"`
-	Dose         *Quantity `json:"dose,omitempty"`
-	Route        *Code     `json:"route,omitempty"`
-	Frequency    *Code     `json:"frequency,omitempty"`
-	InstanceType string    `json:"instanceType"`
+type ReferenceIdentifier struct {
+	ID           string  `json:"id"`
+	Text         string  `json:"text"`
+	ScopeID      *string `json:"scopeId,omitempty"`
+	Type         *Code   `json:"type,omitempty"`
+	InstanceType string  `json:"instanceType"`
+}
+
+type GovernanceDate struct {
+	ID           string  `json:"id"`
+	Name         *string `json:"name,omitempty"`
+	Label        *string `json:"label,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	Type         *Code   `json:"type,omitempty"`
+	DateValue    *string `json:"dateValue,omitempty"`
+	InstanceType string  `json:"instanceType"`
 }
 
+type Abbreviation struct {
+	ID              string `json:"id"`
+	AbbreviatedText string `json:"abbreviatedText"`
+	ExpandedText    string `json:"expandedText"`
+	InstanceType    string `json:"instanceType"`
+}
+
+type StudyRole struct {
+	ID              string   `json:"id"`
+	Name            *string  `json:"name,omitempty"`
+	Label           *string  `json:"label,omitempty"`
+	Description     *string  `json:"description,omitempty"`
+	Code            *Code    `json:"code,omitempty"`
+	OrganizationIDs []string `json:"organizationIds,omitempty"`
+	AppliesToIDs    []string `json:"appliesToIds,omitempty"`
+	InstanceType    string   `json:"instanceType"`
+}
+
+type EligibilityCriterionItem struct {
+	ID           string  `json:"id"`
+	Name         *string `json:"name,omitempty"`
+	Label        *string `json:"label,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	Text         *string `json:"text,omitempty"`
+	DictionaryID *string `json:"dictionaryId,omitempty"`
+	InstanceType string  `json:"instanceType"`
+}
+
+type NarrativeContentItem struct {
+	ID           string  `json:"id"`
+	Name         *string `json:"name,omitempty"`
+	Text         *string `json:"text,omitempty"`
+	InstanceType string  `json:"instanceType"`
+}
+
+type Administration struct {
+	ID              string    `json:"id"`
+	Name            *string   `json:"name,omitempty"`
+	Label           *string   `json:"label,omitempty"`
+	Description     *string   `json:"description,omitempty"`
+	Duration        *Quantity `json:"duration,omitempty"`
+	Dose            *Quantity `json:"dose,omitempty"`
+	Route           *Code     `json:"route,omitempty"`
+	Frequency       *Code     `json:"frequency,omitempty"`
+	AdministrableID *string   `json:"administrableProductId,omitempty"`
+	InstanceType    string    `json:"instanceType"`
+}
+
+type AdministrableProduct struct {
+	ID                    string     `json:"id"`
+	Name                  *string    `json:"name,omitempty"`
+	Label                 *string    `json:"label,omitempty"`
+	Description           *string    `json:"description,omitempty"`
+	AdministrableDoseForm *AliasCode `json:"administrableDoseForm,omitempty"`
+	PharmacologicClass    *Code      `json:"pharmacologicClass,omitempty"`
+	InstanceType          string     `json:"instanceType"`
+}
 
 type BioMedicalConceptCode struct {
-    ID                string `json:"id"`
-    Code              string `json:"code"`
-    CodeSystem        string `json:"codeSystem"`
-    CodeSystemVersion *string `json:"codeSystemVersion,omitempty"` // optional
-    Decode            string `json:"decode"`
-    InstanceType      string `json:"instanceType"`
+	ID                string  `json:"id"`
+	Code              string  `json:"code"`
+	CodeSystem        string  `json:"codeSystem"`
+	CodeSystemVersion *string `json:"codeSystemVersion,omitempty"` // optional
+	Decode            string  `json:"decode"`
+	InstanceType      string  `json:"instanceType"`
 }
 
 type BioMedicalConcept struct {
-    ID           string                  `json:"id"`
-    Name         *string                 `json:"name,omitempty"`   // optional
-    Label        *string                 `json:"label,omitempty"`  // optional
-    Reference    *string                 `json:"reference,omitempty"` // optional
-    InstanceType string                  `json:"instanceType"`
-    Synonyms     []string                `json:"synonyms"`
-    Code         *BioMedicalConceptCode `json:"code"`
+	ID           string                 `json:"id"`
+	Name         *string                `json:"name,omitempty"`        // optional
+	Label        *string                `json:"label,omitempty"`       // optional
+	Description  *string                `json:"description,omitempty"` // optional
+	Reference    *string                `json:"reference,omitempty"`   // optional
+	InstanceType string                 `json:"instanceType"`
+	Synonyms     []string               `json:"synonyms"`
+	Code         *BioMedicalConceptCode `json:"code"`
+}
+
+type BCCategory struct {
+	ID           string   `json:"id"`
+	Name         *string  `json:"name,omitempty"`
+	Label        *string  `json:"label,omitempty"`
+	Description  *string  `json:"description,omitempty"`
+	Code         *Code    `json:"code,omitempty"`
+	MemberIDs    []string `json:"memberIds,omitempty"`
+	ChildIDs     []string `json:"childIds,omitempty"`
+	InstanceType string   `json:"instanceType"`
 }
 
 type BCSurrogate struct {
-    ID           string  `json:"id"`
-    Name         *string `json:"name,omitempty"`
-    Label        *string `json:"label,omitempty"`
-    Description  *string `json:"description,omitempty"`
-    Reference    *string `json:"reference,omitempty"`
-    InstanceType string  `json:"instanceType"`
+	ID           string  `json:"id"`
+	Name         *string `json:"name,omitempty"`
+	Label        *string `json:"label,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	Reference    *string `json:"reference,omitempty"`
+	InstanceType string  `json:"instanceType"`
 }
 
 type Conditions struct {
-    ID           string   `json:"id"`
-    Name         *string  `json:"name,omitempty"`
-    Label        *string  `json:"label,omitempty"`
-    Description  *string  `json:"description,omitempty"`
-    Text         *string  `json:"text,omitempty"`
-    ContextIds   []string `json:"contextIds"`
-    AppliesToIds []string `json:"appliesToIds"`
+	ID           string   `json:"id"`
+	Name         *string  `json:"name,omitempty"`
+	Label        *string  `json:"label,omitempty"`
+	Description  *string  `json:"description,omitempty"`
+	Text         *string  `json:"text,omitempty"`
+	ContextIds   []string `json:"contextIds"`
+	AppliesToIds []string `json:"appliesToIds"`
+	InstanceType string   `json:"instanceType,omitempty"`
 }
 
 type StudyDesign struct {
-	ID          string       `json:"id"`
-	Name        *string      `json:"name,omitempty"`
-	Description *string      `json:"description,omitempty"`
-	StudyType   *StudyType   `json:"studyType,omitempty"`
-	Arms        []*Arm       `json:"arms,omitempty"`
-	Epochs      []*Epoch     `json:"epochs,omitempty"`
-	Elements    []*Element   `json:"elements,omitempty"`
-	StudyCells  []*StudyCell `json:"studyCells,omitempty"`
-	Encounters  []*Encounter `json:"encounters,omitempty"`
-	Activities  []*Activity  `json:"activities,omitempty"`
+	ID                   string                  `json:"id"`
+	Name                 *string                 `json:"name,omitempty"`
+	Label                *string                 `json:"label,omitempty"`
+	Description          *string                 `json:"description,omitempty"`
+	Rationale            *string                 `json:"rationale,omitempty"`
+	StudyType            *StudyType              `json:"studyType,omitempty"`
+	StudyPhase           *AliasCode              `json:"studyPhase,omitempty"`
+	Model                *Code                   `json:"model,omitempty"`
+	BlindingSchema       *AliasCode              `json:"blindingSchema,omitempty"`
+	TherapeuticAreas     []*Code                 `json:"therapeuticAreas,omitempty"`
+	Characteristics      []*Code                 `json:"characteristics,omitempty"`
+	IntentTypes          []*Code                 `json:"intentTypes,omitempty"`
+	SubTypes             []*Code                 `json:"subTypes,omitempty"`
+	Arms                 []*Arm                  `json:"arms,omitempty"`
+	Epochs               []*Epoch                `json:"epochs,omitempty"`
+	Elements             []*Element              `json:"elements,omitempty"`
+	StudyCells           []*StudyCell            `json:"studyCells,omitempty"`
+	Encounters           []*Encounter            `json:"encounters,omitempty"`
+	Activities           []*Activity             `json:"activities,omitempty"`
+	Objectives           []*Objective            `json:"objectives,omitempty"`
+	Population           *StudyDesignPopulation  `json:"population,omitempty"`
+	EligibilityCriteria  []*EligibilityCriterion `json:"eligibilityCriteria,omitempty"`
+	Indications          []*Indication           `json:"indications,omitempty"`
+	Estimands            []*Estimand             `json:"estimands,omitempty"`
+	StudyInterventionIDs []string                `json:"studyInterventionIds,omitempty"`
+	ScheduleTimelines    []*ScheduleTimeline     `json:"scheduleTimelines,omitempty"`
+	StudyVersion         *StudyVersion           `json:"studyVersion,omitempty"`
+	InstanceType         string                  `json:"instanceType,omitempty"`
+}
+
+// ScheduleTimeline is a USDM schedule: scheduled instances, the timings
+// between them and the exits that end it. The main timeline of a design
+// places activities at encounters; others are entered from an activity.
+type ScheduleTimeline struct {
+	ID             string                  `json:"id"`
+	Name           *string                 `json:"name,omitempty"`
+	Label          *string                 `json:"label,omitempty"`
+	Description    *string                 `json:"description,omitempty"`
+	MainTimeline   bool                    `json:"mainTimeline"`
+	EntryCondition *string                 `json:"entryCondition,omitempty"`
+	EntryID        *string                 `json:"entryId,omitempty"`
+	Exits          []*ScheduleTimelineExit `json:"exits,omitempty"`
+	Timings        []*Timing               `json:"timings,omitempty"`
+	Instances      []*ScheduledInstance    `json:"instances,omitempty"`
+	StudyDesign    *StudyDesign            `json:"studyDesign,omitempty"`
+	InstanceType   string                  `json:"instanceType,omitempty"`
+}
+
+type ScheduleTimelineExit struct {
+	ID           string `json:"id"`
+	InstanceType string `json:"instanceType,omitempty"`
+}
+
+// ScheduledInstance covers both ScheduledActivityInstance and
+// ScheduledDecisionInstance, told apart by InstanceType.
+type ScheduledInstance struct {
+	ID                 string      `json:"id"`
+	Name               *string     `json:"name,omitempty"`
+	Label              *string     `json:"label,omitempty"`
+	Description        *string     `json:"description,omitempty"`
+	TimelineID         *string     `json:"timelineId,omitempty"`
+	TimelineExitID     *string     `json:"timelineExitId,omitempty"`
+	DefaultConditionID *string     `json:"defaultConditionId,omitempty"`
+	EpochID            *string     `json:"epochId,omitempty"`
+	EncounterID        *string     `json:"encounterId,omitempty"`
+	ActivityIDs        []string    `json:"activityIds,omitempty"`
+	Activities         []*Activity `json:"activities,omitempty"`
+	Encounter          *Encounter  `json:"encounter,omitempty"`
+	Epoch              *Epoch      `json:"epoch,omitempty"`
+	InstanceType       string      `json:"instanceType,omitempty"`
+}
+
+// Timing places the scheduled instance relativeFromScheduledInstanceId at
+// Value, an ISO 8601 duration, from relativeToScheduledInstanceId.
+type Timing struct {
+	ID                              string  `json:"id"`
+	Name                            *string `json:"name,omitempty"`
+	Label                           *string `json:"label,omitempty"`
+	Description                     *string `json:"description,omitempty"`
+	Type                            *Code   `json:"type,omitempty"`
+	Value                           *string `json:"value,omitempty"`
+	ValueLabel                      *string `json:"valueLabel,omitempty"`
+	RelativeToFrom                  *Code   `json:"relativeToFrom,omitempty"`
+	RelativeFromScheduledInstanceID *string `json:"relativeFromScheduledInstanceId,omitempty"`
+	RelativeToScheduledInstanceID   *string `json:"relativeToScheduledInstanceId,omitempty"`
+	WindowLower                     *string `json:"windowLower,omitempty"`
+	WindowUpper                     *string `json:"windowUpper,omitempty"`
+	WindowLabel                     *string `json:"windowLabel,omitempty"`
+	InstanceType                    string  `json:"instanceType,omitempty"`
+}
+
+// ScheduleOfActivities is the SoA grid of one study design: its epochs,
+// encounters and activities in schedule order and one cell per activity
+// scheduled at an encounter.
+type ScheduleOfActivities struct {
+	StudyDesignID string       `json:"studyDesignId"`
+	Epochs        []*Epoch     `json:"epochs"`
+	Encounters    []*Encounter `json:"encounters"`
+	Activities    []*Activity  `json:"activities"`
+	Cells         []*SoACell   `json:"cells"`
+}
+
+type SoACell struct {
+	ActivityID          string  `json:"activityId"`
+	EncounterID         string  `json:"encounterId"`
+	EpochID             *string `json:"epochId,omitempty"`
+	ScheduledInstanceID string  `json:"scheduledInstanceId"`
+	TimelineID          string  `json:"timelineId"`
+	Timing              *Timing `json:"timing,omitempty"`
 }
 
 type Encounter struct {
-	ID            string         `json:"id"`
-	Name          *string        `json:"name,omitempty"`
-	Label         *string        `json:"label,omitempty"`
-	Description   *string        `json:"description,omitempty"`
-	Type          *EncounterType `json:"type,omitempty"`
-	PreviousID    *string        `json:"previousId,omitempty"`    // ADDED: This field was missing
-	NextID        *string        `json:"nextId,omitempty"`        // ADDED: This field was missing
-	ScheduledAtID *string        `json:"scheduledAtId,omitempty"` // ADDED: This field was missing
+	ID                    string          `json:"id"`
+	StudyID               string          `json:"studyId,omitempty"`
+	Name                  *string         `json:"name,omitempty"`
+	Label                 *string         `json:"label,omitempty"`
+	Description           *string         `json:"description,omitempty"`
+	Type                  *EncounterType  `json:"type,omitempty"`
+	PreviousID            *string         `json:"previousId,omitempty"`
+	NextID                *string         `json:"nextId,omitempty"`
+	ScheduledAtID         *string         `json:"scheduledAtId,omitempty"`
+	ScheduledAt           *Timing         `json:"scheduledAt,omitempty"`
+	EnvironmentalSettings []*Code         `json:"environmentalSettings,omitempty"`
+	ContactModes          []*Code         `json:"contactModes,omitempty"`
+	TransitionStartRule   *TransitionRule `json:"transitionStartRule,omitempty"`
+	TransitionEndRule     *TransitionRule `json:"transitionEndRule,omitempty"`
+	StudyDesigns          []*StudyDesign  `json:"studyDesigns,omitempty"`
+	InstanceType          string          `json:"instanceType,omitempty"`
 }
 
 type EncounterType struct {
@@ -113,13 +325,32 @@ type EncounterType struct {
 	InstanceType      string `json:"instanceType"`
 }
 
+type TransitionRule struct {
+	ID           string  `json:"id"`
+	Name         *string `json:"name,omitempty"`
+	Label        *string `json:"label,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	Text         string  `json:"text"`
+	InstanceType string  `json:"instanceType"`
+}
+
 type Activity struct {
-	ID                string              `json:"id"`
-	Name              *string             `json:"name,omitempty"`
-	Label             *string             `json:"label,omitempty"`
-	Description       *string             `json:"description,omitempty"`
-	DefinedProcedures []*DefinedProcedure `json:"definedProcedures,omitempty"`
-	InstanceType      string              `json:"instanceType"`
+	ID                   string              `json:"id"`
+	StudyID              string              `json:"studyId,omitempty"`
+	Name                 *string             `json:"name,omitempty"`
+	Label                *string             `json:"label,omitempty"`
+	Description          *string             `json:"description,omitempty"`
+	PreviousID           *string             `json:"previousId,omitempty"`
+	NextID               *string             `json:"nextId,omitempty"`
+	ChildIDs             []string            `json:"childIds,omitempty"`
+	DefinedProcedures    []*DefinedProcedure `json:"definedProcedures,omitempty"`
+	BiomedicalConceptIDs []string            `json:"biomedicalConceptIds,omitempty"`
+	BCCategoryIDs        []string            `json:"bcCategoryIds,omitempty"`
+	BCSurrogateIDs       []string            `json:"bcSurrogateIds,omitempty"`
+	TimelineID           *string             `json:"timelineId,omitempty"`
+	Timeline             *ScheduleTimeline   `json:"timeline,omitempty"`
+	StudyDesigns         []*StudyDesign      `json:"studyDesigns,omitempty"`
+	InstanceType         string              `json:"instanceType"`
 }
 
 type DefinedProcedure struct {
@@ -130,7 +361,7 @@ type DefinedProcedure struct {
 	ProcedureType       *string `json:"procedureType,omitempty"`
 	Code                *Code   `json:"code,omitempty"`
 	StudyInterventionID *string `json:"studyInterventionId,omitempty"`
-	instanceType        string  `json:"instanceType"`
+	InstanceType        string  `json:"instanceType"`
 }
 
 type Code struct {
@@ -142,6 +373,14 @@ type Code struct {
 	InstanceType      string `json:"instanceType"`
 }
 
+// AliasCode is a standard code together with the sponsor's aliases for it.
+type AliasCode struct {
+	ID                  string  `json:"id"`
+	StandardCode        *Code   `json:"standardCode,omitempty"`
+	StandardCodeAliases []*Code `json:"standardCodeAliases,omitempty"`
+	InstanceType        string  `json:"instanceType"`
+}
+
 type StudyType struct {
 	ID                string `json:"id"`
 	Code              string `json:"code"`
@@ -152,11 +391,16 @@ type StudyType struct {
 }
 
 type Arm struct {
-	ID             string             `json:"id"`
-	Name           *string            `json:"name,omitempty"`
-	Description    *string            `json:"description,omitempty"`
-	DataOriginType *ArmDataOriginType `json:"type,omitempty"` // FIXED: JSON tag was "type"
-	StudyDesign    *StudyDesign       `json:"studyDesign,omitempty"`
+	ID                    string             `json:"id"`
+	Name                  *string            `json:"name,omitempty"`
+	Label                 *string            `json:"label,omitempty"`
+	Description           *string            `json:"description,omitempty"`
+	Type                  *Code              `json:"type,omitempty"`
+	DataOriginDescription *string            `json:"dataOriginDescription,omitempty"`
+	DataOriginType        *ArmDataOriginType `json:"dataOriginType,omitempty"`
+	PopulationIDs         []string           `json:"populationIds,omitempty"`
+	StudyDesign           *StudyDesign       `json:"studyDesign,omitempty"`
+	InstanceType          string             `json:"instanceType,omitempty"`
 }
 
 type ArmDataOriginType struct {
@@ -170,42 +414,133 @@ type ArmDataOriginType struct {
 }
 
 type Epoch struct {
-	ID          string  `json:"id"`
-	Name        *string `json:"name,omitempty"`
-	Description *string `json:"description,omitempty"`
-	PreviousID  *string `json:"previousId,omitempty"` // ADDED: This field was missing
-	Precedes    *Epoch  `json:"precedes,omitempty"`
-	PrecededBy  *Epoch  `json:"precededBy,omitempty"`
+	ID           string       `json:"id"`
+	Name         *string      `json:"name,omitempty"`
+	Label        *string      `json:"label,omitempty"`
+	Description  *string      `json:"description,omitempty"`
+	Type         *Code        `json:"type,omitempty"`
+	PreviousID   *string      `json:"previousId,omitempty"`
+	NextID       *string      `json:"nextId,omitempty"`
+	Precedes     *Epoch       `json:"precedes,omitempty"`
+	PrecededBy   *Epoch       `json:"precededBy,omitempty"`
+	StudyDesign  *StudyDesign `json:"studyDesign,omitempty"`
+	InstanceType string       `json:"instanceType,omitempty"`
 }
 
 type Element struct {
-	ID          string  `json:"id"`
-	Name        *string `json:"name,omitempty"`
-	Description *string `json:"description,omitempty"`
+	ID                   string          `json:"id"`
+	Name                 *string         `json:"name,omitempty"`
+	Label                *string         `json:"label,omitempty"`
+	Description          *string         `json:"description,omitempty"`
+	TransitionStartRule  *TransitionRule `json:"transitionStartRule,omitempty"`
+	TransitionEndRule    *TransitionRule `json:"transitionEndRule,omitempty"`
+	StudyInterventionIDs []string        `json:"studyInterventionIds,omitempty"`
+	StudyDesign          *StudyDesign    `json:"studyDesign,omitempty"`
+	InstanceType         string          `json:"instanceType,omitempty"`
 }
 
 type StudyCell struct {
-	ID       string     `json:"id"`
-	Arm      *Arm       `json:"arm,omitempty"`
-	Epoch    *Epoch     `json:"epoch,omitempty"`
-	Elements []*Element `json:"elements,omitempty"`
+	ID           string       `json:"id"`
+	ArmID        string       `json:"armId,omitempty"`
+	EpochID      string       `json:"epochId,omitempty"`
+	ElementIDs   []string     `json:"elementIds,omitempty"`
+	Arm          *Arm         `json:"arm,omitempty"`
+	Epoch        *Epoch       `json:"epoch,omitempty"`
+	Elements     []*Element   `json:"elements,omitempty"`
+	StudyDesign  *StudyDesign `json:"studyDesign,omitempty"`
+	InstanceType string       `json:"instanceType,omitempty"`
+}
+
+type Objective struct {
+	ID           string      `json:"id"`
+	Name         *string     `json:"name,omitempty"`
+	Label        *string     `json:"label,omitempty"`
+	Description  *string     `json:"description,omitempty"`
+	Text         *string     `json:"text,omitempty"`
+	Level        *Code       `json:"level,omitempty"`
+	Endpoints    []*Endpoint `json:"endpoints,omitempty"`
+	InstanceType string      `json:"instanceType"`
+}
+
+type Endpoint struct {
+	ID           string  `json:"id"`
+	Name         *string `json:"name,omitempty"`
+	Label        *string `json:"label,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	Text         *string `json:"text,omitempty"`
+	Purpose      *string `json:"purpose,omitempty"`
+	Level        *Code   `json:"level,omitempty"`
+	InstanceType string  `json:"instanceType"`
+}
+
+type StudyDesignPopulation struct {
+	ID                      string   `json:"id"`
+	Name                    *string  `json:"name,omitempty"`
+	Label                   *string  `json:"label,omitempty"`
+	Description             *string  `json:"description,omitempty"`
+	IncludesHealthySubjects *bool    `json:"includesHealthySubjects,omitempty"`
+	PlannedSex              []*Code  `json:"plannedSex,omitempty"`
+	CriterionIDs            []string `json:"criterionIds,omitempty"`
+	InstanceType            string   `json:"instanceType"`
+}
+
+type EligibilityCriterion struct {
+	ID              string  `json:"id"`
+	Name            *string `json:"name,omitempty"`
+	Label           *string `json:"label,omitempty"`
+	Description     *string `json:"description,omitempty"`
+	Category        *Code   `json:"category,omitempty"`
+	Identifier      *string `json:"identifier,omitempty"`
+	CriterionItemID *string `json:"criterionItemId,omitempty"`
+	PreviousID      *string `json:"previousId,omitempty"`
+	NextID          *string `json:"nextId,omitempty"`
+	ContextID       *string `json:"contextId,omitempty"`
+	InstanceType    string  `json:"instanceType"`
+}
+
+type Indication struct {
+	ID            string  `json:"id"`
+	Name          *string `json:"name,omitempty"`
+	Label         *string `json:"label,omitempty"`
+	Description   *string `json:"description,omitempty"`
+	Codes         []*Code `json:"codes,omitempty"`
+	IsRareDisease *bool   `json:"isRareDisease,omitempty"`
+	InstanceType  string  `json:"instanceType"`
+}
+
+type Estimand struct {
+	ID                   string   `json:"id"`
+	Name                 *string  `json:"name,omitempty"`
+	Label                *string  `json:"label,omitempty"`
+	Description          *string  `json:"description,omitempty"`
+	PopulationSummary    *string  `json:"populationSummary,omitempty"`
+	AnalysisPopulationID *string  `json:"analysisPopulationId,omitempty"`
+	VariableOfInterestID *string  `json:"variableOfInterestId,omitempty"`
+	InterventionIDs      []string `json:"interventionIds,omitempty"`
+	InstanceType         string   `json:"instanceType"`
 }
 
 type StudyAmendment struct {
-	ID        string        `json:"id"`
-	Name      *string       `json:"name,omitempty"`
-	Summary   *string       `json:"summary,omitempty"`
-	Description *string       `json:"description,omitempty"`
-	Label		 *string       `json:"label,omitempty"`
-	Number		 *string       `json:"number,omitempty"`
-	PrimaryReason *PrimaryReason `json:"primaryReason,omitempty"`
-	Enrollments []*Enrollment `json:"enrollments,omitempty"`
+	ID                string            `json:"id"`
+	Name              *string           `json:"name,omitempty"`
+	Summary           *string           `json:"summary,omitempty"`
+	Description       *string           `json:"description,omitempty"`
+	Label             *string           `json:"label,omitempty"`
+	Number            *string           `json:"number,omitempty"`
+	SubstantialImpact *bool             `json:"substantialImpact,omitempty"`
+	PreviousID        *string           `json:"previousId,omitempty"`
+	PrimaryReason     *PrimaryReason    `json:"primaryReason,omitempty"`
+	SecondaryReasons  []*PrimaryReason  `json:"secondaryReasons,omitempty"`
+	Enrollments       []*Enrollment     `json:"enrollments,omitempty"`
+	DateValues        []*GovernanceDate `json:"dateValues,omitempty"`
+	InstanceType      string            `json:"instanceType,omitempty"`
 }
 
 type PrimaryReason struct {
-	ID                string `json:"id"`	
-	Code              *PrimaryReasonCode `json:"code"`
-	InstanceType      string `json:"instanceType"`
+	ID           string             `json:"id"`
+	Code         *PrimaryReasonCode `json:"code"`
+	OtherReason  *string            `json:"otherReason,omitempty"`
+	InstanceType string             `json:"instanceType"`
 }
 
 type PrimaryReasonCode struct {
@@ -217,30 +552,35 @@ type PrimaryReasonCode struct {
 	InstanceType      string `json:"instanceType"`
 }
 
-
 type Enrollment struct {
-	ID          string  `json:"id"`
-	Name        *string `json:"name,omitempty"`
-	Quantity *Quantity `json:"quantity,omitempty"`
+	ID           string    `json:"id"`
+	Name         *string   `json:"name,omitempty"`
+	Label        *string   `json:"label,omitempty"`
+	Description  *string   `json:"description,omitempty"`
+	Quantity     *Quantity `json:"quantity,omitempty"`
+	InstanceType string    `json:"instanceType,omitempty"`
 }
 
 type Quantity struct {
-	ID          string  `json:"id"`
-	Value       *int `json:"value,omitempty"`
-	Unit         *string `json:"unit,omitempty"`
-	CodeSystem   *string `json:"codeSystem,omitempty"`
-	InstanceType string  `json:"instanceType"`
+	ID           string   `json:"id"`
+	Value        *float64 `json:"value,omitempty"`
+	Unit         *Code    `json:"unit,omitempty"`
+	InstanceType string   `json:"instanceType"`
 }
 
 type StudyIntervention struct {
-	ID          string  `json:"id"`
-	Name        *string `json:"name,omitempty"`
-	Description *string `json:"description,omitempty"`
-	Role        *string `json:"role,omitempty"`
-	Type        *string `json:"type,omitempty"`
-	MinimumResponseDuration *Quantity         `json:"minimumResponseDuration,omitempty"` 
+	ID                      string            `json:"id"`
+	Name                    *string           `json:"name,omitempty"`
+	Label                   *string           `json:"label,omitempty"`
+	Description             *string           `json:"description,omitempty"`
+	Role                    *Code             `json:"role,omitempty"`
+	Type                    *Code             `json:"type,omitempty"`
+	Codes                   []*Code           `json:"codes,omitempty"`
+	ProductDesignation      *Code             `json:"productDesignation,omitempty"`
+	PharmacologicClass      *Code             `json:"pharmacologicClass,omitempty"`
+	MinimumResponseDuration *Quantity         `json:"minimumResponseDuration,omitempty"`
 	Administrations         []*Administration `json:"administrations,omitempty"`
-	InstanceType            string
+	InstanceType            string            `json:"instanceType"`
 }
 
 type OrgType struct {
@@ -253,10 +593,14 @@ type OrgType struct {
 }
 
 type Organization struct {
-	ID           string        `json:"id"`
-	Name         *string       `json:"name,omitempty"`
-	Type         *OrgType      `json:"type,omitempty"`
-	LegalAddress *LegalAddress `json:"legalAddress,omitempty"`
+	ID               string        `json:"id"`
+	Name             *string       `json:"name,omitempty"`
+	Label            *string       `json:"label,omitempty"`
+	Identifier       *string       `json:"identifier,omitempty"`
+	IdentifierScheme *string       `json:"identifierScheme,omitempty"`
+	Type             *OrgType      `json:"type,omitempty"`
+	LegalAddress     *LegalAddress `json:"legalAddress,omitempty"`
+	InstanceType     string        `json:"instanceType,omitempty"`
 }
 
 type Country struct {
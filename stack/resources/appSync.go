@@ -20,12 +20,16 @@ func NewAppSyncApi( stack awscdk.Stack, vpc awsec2.Vpc, resolverFunc awslambda.I
 					Expires: awscdk.Expiration_After(awscdk.Duration_Days(jsii.Number(365))),
 				},
 			},
+			// The SDRProcessor signs the mutations behind the subscriptions.
+			AdditionalAuthorizationModes: &[]*appsync.AuthorizationMode{
+				{AuthorizationType: appsync.AuthorizationType_IAM},
+			},
 		},
 	})
 
 	ds := appSyncAPI.AddLambdaDataSource(jsii.String("ResolverDS"), resolverFunc, nil)
 
-	for _, field := range []string{"study", "studies", "studyVersion", "organization", "encounters", "activities", "graphStats"}  {
+	for _, field := range []string{"study", "studies", "studyRevisions", "studyVersion", "organization", "encounters", "activities", "scheduleOfActivities", "compareStudyVersions", "graphStats", "exportStudy", "exportStudyFhir", "exportStudyRdf"}  {
 		ds.CreateResolver(&field, &appsync.BaseResolverProps{
 			TypeName:  jsii.String("Query"),
 			FieldName: jsii.String(field),
@@ -37,6 +41,13 @@ func NewAppSyncApi( stack awscdk.Stack, vpc awsec2.Vpc, resolverFunc awslambda.I
 		FieldName: jsii.String("deleteStudy"),
 	})
 
+	for _, field := range []string{"publishStudyIngestion", "publishSubmissionStatus"} {
+		ds.CreateResolver(&field, &appsync.BaseResolverProps{
+			TypeName:  jsii.String("Mutation"),
+			FieldName: jsii.String(field),
+		})
+	}
+
 
 	return appSyncAPI
 }
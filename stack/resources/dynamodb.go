@@ -0,0 +1,32 @@
+package resources
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/jsii-runtime-go"
+)
+
+
+// NewSubmissionTable holds one status record per SDR submission, keyed by
+// the submission id returned from POST /sdr.
+func NewSubmissionTable(stack awscdk.Stack, vpc awsec2.Vpc) awsdynamodb.Table {
+
+	table := awsdynamodb.NewTable(stack, jsii.String("SubmissionStatusTable"), &awsdynamodb.TableProps{
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("id"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode: awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		PointInTimeRecoverySpecification: &awsdynamodb.PointInTimeRecoverySpecification{
+			PointInTimeRecoveryEnabled: jsii.Bool(true),
+		},
+	})
+
+	// The lambdas run inside the VPC; keep their DynamoDB traffic off the NAT.
+	vpc.AddGatewayEndpoint(jsii.String("DynamoDBEndpoint"), &awsec2.GatewayVpcEndpointOptions{
+		Service: awsec2.GatewayVpcEndpointAwsService_DYNAMODB(),
+	})
+
+	return table
+}
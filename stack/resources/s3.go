@@ -0,0 +1,49 @@
+package resources
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/jsii-runtime-go"
+)
+
+
+// NewIngestionBucket receives SDR files for bulk ingestion; every object
+// created in it is read by sdrBulkIngest, which writes its report back
+// next to it.
+func NewIngestionBucket(stack awscdk.Stack, vpc awsec2.Vpc) awss3.Bucket {
+
+	bucket := awss3.NewBucket(stack, jsii.String("SDRIngestionBucket"), &awss3.BucketProps{
+		Encryption:        awss3.BucketEncryption_S3_MANAGED,
+		BlockPublicAccess: awss3.BlockPublicAccess_BLOCK_ALL(),
+		EnforceSSL:        jsii.Bool(true),
+	})
+
+	// Bulk files can be large; keep their download off the NAT.
+	vpc.AddGatewayEndpoint(jsii.String("S3Endpoint"), &awsec2.GatewayVpcEndpointOptions{
+		Service: awsec2.GatewayVpcEndpointAwsService_S3(),
+	})
+
+	return bucket
+}
+
+// NewPayloadBucket holds SDR payloads too large to send on the queue; see
+// internal/claimcheck. A payload is needed until its message is processed
+// or leaves the dead letter queue, so it expires once neither queue can
+// still hold the message.
+func NewPayloadBucket(stack awscdk.Stack) awss3.Bucket {
+
+	return awss3.NewBucket(stack, jsii.String("SDRPayloadBucket"), &awss3.BucketProps{
+		Encryption:        awss3.BucketEncryption_S3_MANAGED,
+		BlockPublicAccess: awss3.BlockPublicAccess_BLOCK_ALL(),
+		EnforceSSL:        jsii.Bool(true),
+		LifecycleRules: &[]*awss3.LifecycleRule{
+			{
+				Id:                                  jsii.String("ExpireClaimCheckPayloads"),
+				Prefix:                              jsii.String("payloads/"),
+				Expiration:                          awscdk.Duration_Days(jsii.Number(21)),
+				AbortIncompleteMultipartUploadAfter: awscdk.Duration_Days(jsii.Number(1)),
+			},
+		},
+	})
+}
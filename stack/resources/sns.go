@@ -0,0 +1,18 @@
+package resources
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssns"
+	"github.com/aws/jsii-runtime-go"
+)
+
+
+// NewStudyEventsTopic carries the study events of internal/studyevent to
+// downstream systems. Subscribers can filter on the eventType and studyId
+// message attributes.
+func NewStudyEventsTopic(stack awscdk.Stack) awssns.Topic {
+
+	return awssns.NewTopic(stack, jsii.String("StudyEventsTopic"), &awssns.TopicProps{
+		DisplayName: jsii.String("Study Events Topic"),
+	})
+}
@@ -1,12 +1,16 @@
 package stack
 
 import (
+	"strconv"
+
 	"github.com/ankit-lilly/dtd-go-backend/stack/resources"
 
 	"github.com/aws/aws-cdk-go/awscdk/v2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsapigateway"
 	ec2 "github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awslambdaeventsources"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3notifications"
 	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
 )
@@ -20,6 +24,11 @@ func NewSDRBackendStack(scope constructs.Construct, id string, sprops awscdk.Sta
 
 	cluster := resources.NewNeptuneDB(stack, vpc)
 	queue := resources.NewSQSQueue(stack, vpc)
+	deadLetterQueue := queue.DeadLetterQueue()
+	submissionTable := resources.NewSubmissionTable(stack, vpc)
+	ingestionBucket := resources.NewIngestionBucket(stack, vpc)
+	payloadBucket := resources.NewPayloadBucket(stack)
+	studyEventsTopic := resources.NewStudyEventsTopic(stack)
 	apiGateway := resources.NewApiGateway(stack)
 	lambdaRole := resources.NewLambdaRole(stack)
 	lambdaFactory := resources.NewLambdaFactory(stack, vpc, lambdaRole)
@@ -30,10 +39,14 @@ func NewSDRBackendStack(scope constructs.Construct, id string, sprops awscdk.Sta
 		"lambdas/sdrHandler/function.zip", 
 		map[string]*string{ 
 			"QUEUE_URL": queue.QueueUrl(),
+			"SUBMISSION_TABLE": submissionTable.TableName(),
+			"PAYLOAD_BUCKET": payloadBucket.BucketName(),
 		},
   )
 
 	queue.GrantSendMessages(sdrHandler)
+	submissionTable.GrantReadWriteData(sdrHandler)
+	payloadBucket.GrantPut(sdrHandler, nil)
 
 	sdrProcessor := lambdaFactory.CreateGoFunction(
 		"sdrProcessor", 
@@ -41,8 +54,39 @@ func NewSDRBackendStack(scope constructs.Construct, id string, sprops awscdk.Sta
 		map[string]*string{
 			"NEPTUNE_ENDPOINT": cluster.ClusterEndpoint().Hostname(),
 			"NEPTUNE_PORT":     jsii.String("8182"),
+			"SUBMISSION_TABLE": submissionTable.TableName(),
+			"DLQ_URL":          deadLetterQueue.Queue.QueueUrl(),
+			"MAX_RECEIVE_COUNT": jsii.String(strconv.Itoa(int(*deadLetterQueue.MaxReceiveCount))),
+			"BATCH_CONCURRENCY": jsii.String("5"),
+			"STUDY_EVENTS_TOPIC_ARN": studyEventsTopic.TopicArn(),
 	})
 
+	submissionTable.GrantReadWriteData(sdrProcessor)
+	payloadBucket.GrantRead(sdrProcessor, nil)
+	deadLetterQueue.Queue.GrantSendMessages(sdrProcessor)
+	studyEventsTopic.GrantPublish(sdrProcessor)
+
+	// A bulk file can hold thousands of studies, each validated and queued
+	// in turn, so this one gets the longest timeout Lambda allows.
+	bulkIngestConfig := resources.DefaultLambdaConfig()
+	bulkIngestConfig.Name = "sdrBulkIngest"
+	bulkIngestConfig.CodePath = "lambdas/sdrBulkIngest/function.zip"
+	bulkIngestConfig.Timeout = awscdk.Duration_Minutes(jsii.Number(15))
+	bulkIngestConfig.MemorySize = jsii.Number(1024)
+	bulkIngestConfig.Description = jsii.String("Go Lambda function: sdrBulkIngest")
+	bulkIngestConfig.Environment = map[string]*string{
+		"QUEUE_URL":        queue.QueueUrl(),
+		"SUBMISSION_TABLE": submissionTable.TableName(),
+		"PAYLOAD_BUCKET":   payloadBucket.BucketName(),
+	}
+	sdrBulkIngest := lambdaFactory.CreateFunction(bulkIngestConfig)
+
+	queue.GrantSendMessages(sdrBulkIngest)
+	submissionTable.GrantReadWriteData(sdrBulkIngest)
+	ingestionBucket.GrantReadWrite(sdrBulkIngest, nil)
+	payloadBucket.GrantPut(sdrBulkIngest, nil)
+	ingestionBucket.AddEventNotification(awss3.EventType_OBJECT_CREATED, awss3notifications.NewLambdaDestination(sdrBulkIngest))
+
 	resolverFn :=  lambdaFactory.CreateGoFunction(
 		"resolverFunction", 
 		"lambdas/resolver/function.zip", 
@@ -50,15 +94,47 @@ func NewSDRBackendStack(scope constructs.Construct, id string, sprops awscdk.Sta
 			"NEPTUNE_ENDPOINT":        cluster.ClusterEndpoint().Hostname(),
 			"NEPTUNE_READER_ENDPOINT": cluster.ClusterReadEndpoint().Hostname(),
 			"NEPTUNE_PORT":            jsii.String("8182"),
+			"STUDY_EVENTS_TOPIC_ARN":  studyEventsTopic.TopicArn(),
+	})
+
+	studyEventsTopic.GrantPublish(resolverFn)
+
+
+	exporterFn := lambdaFactory.CreateGoFunction(
+		"exporter",
+		"lambdas/exporter/function.zip",
+		map[string]*string{
+			"NEPTUNE_READER_ENDPOINT": cluster.ClusterReadEndpoint().Hostname(),
+			"NEPTUNE_PORT":            jsii.String("8182"),
 	})
 
 
 	appSyncAPI := resources.NewAppSyncApi(stack, vpc, resolverFn)
 
+	sdrProcessor.AddEnvironment(jsii.String("GRAPHQL_URL"), appSyncAPI.GraphqlUrl(), nil)
+	appSyncAPI.GrantMutation(sdrProcessor, jsii.String("publishStudyIngestion"), jsii.String("publishSubmissionStatus"))
+
 	sdrHandlerIntegration := awsapigateway.NewLambdaIntegration(sdrHandler, nil);
 	sdrHandlerResource := apiGateway.Root().AddResource(jsii.String("sdr"), nil)
 	sdrHandlerResource.AddMethod(jsii.String("POST"), sdrHandlerIntegration, nil)
 
+	submissionResource := sdrHandlerResource.AddResource(jsii.String("{id}"), nil)
+	submissionResource.AddMethod(jsii.String("GET"), sdrHandlerIntegration, nil)
+
+	exporterIntegration := awsapigateway.NewLambdaIntegration(exporterFn, nil)
+	compareResource := sdrHandlerResource.AddResource(jsii.String("compare"), nil)
+	compareResource.AddMethod(jsii.String("GET"), exporterIntegration, nil)
+
+	// GET /sdr/{id} already reads a submission, so a study is exported
+	// from /sdr/{id}/usdm with its study id as {id}; API Gateway allows
+	// one path parameter name per level.
+	usdmResource := submissionResource.AddResource(jsii.String("usdm"), nil)
+	usdmResource.AddMethod(jsii.String("GET"), exporterIntegration, nil)
+	fhirResource := submissionResource.AddResource(jsii.String("fhir"), nil)
+	fhirResource.AddMethod(jsii.String("GET"), exporterIntegration, nil)
+	rdfResource := submissionResource.AddResource(jsii.String("rdf"), nil)
+	rdfResource.AddMethod(jsii.String("GET"), exporterIntegration, nil)
+
 	sdrProcessor.AddEventSource(
 		awslambdaeventsources.NewSqsEventSource( 
 			queue, 
@@ -84,6 +160,15 @@ func NewSDRBackendStack(scope constructs.Construct, id string, sprops awscdk.Sta
 		"RestApiEndpoint": {
 			Value: apiGateway.Url(),
 		},
+		"SubmissionStatusTable": {
+			Value: submissionTable.TableName(),
+		},
+		"IngestionBucket": {
+			Value: ingestionBucket.BucketName(),
+		},
+		"StudyEventsTopic": {
+			Value: studyEventsTopic.TopicArn(),
+		},
 	});
 
 	return stack